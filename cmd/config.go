@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// configFileName is the project config file looked up in the working
+// directory when --config is not given.
+const configFileName = "lab.yaml"
+
+// mergedConfigFlags are the flags whose config entries are merged with the
+// entries given on the command line instead of being replaced by them.
+var mergedConfigFlags = map[string]bool{
+	"param":          true,
+	"runtime-param":  true,
+	"secret":         true,
+	"runtime-secret": true,
+}
+
+func configFlags(hidden bool) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "project config file declaring default options by their flag names (default: lab.yaml in the working directory if present)",
+			Sources: cli.EnvVars("LAB_CONFIG"),
+			Hidden:  hidden,
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "named profile of the project config file whose options override the top-level ones",
+			Sources: cli.EnvVars("LAB_PROFILE"),
+			Hidden:  hidden,
+		},
+	}
+}
+
+// applyConfig sets the options declared in the project config file for
+// every flag of the command that was not set on the command line or through
+// its environment variable. Keys are the long names of the 'lab run' flags;
+// aliases map a key to the name of the flag of the command. The options of
+// the selected profile are applied before the top-level ones and therefore
+// win over them.
+func applyConfig(cmd *cli.Command, aliases map[string]string) error {
+	profile := cmd.String("profile")
+
+	path, options, err := loadConfig(cmd.String("config"))
+	if err != nil {
+		return err
+	}
+
+	if options == nil {
+		if profile != "" {
+			return fmt.Errorf("profile %q: config file %s not found", profile, path)
+		}
+
+		return nil
+	}
+
+	var profiles yaml.MapSlice
+
+	top := make(yaml.MapSlice, 0, len(options))
+
+	for _, item := range options {
+		if item.Key != "profiles" {
+			top = append(top, item)
+
+			continue
+		}
+
+		switch v := item.Value.(type) {
+		case yaml.MapSlice:
+			profiles = v
+		case nil:
+		default:
+			return fmt.Errorf("%s: profiles: expected a map of profiles", path)
+		}
+	}
+
+	if profile != "" {
+		selected, err := lookupProfile(profiles, profile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := applyOptions(cmd, path+": profiles."+profile, selected, aliases); err != nil {
+			return err
+		}
+	}
+
+	return applyOptions(cmd, path, top, aliases)
+}
+
+func lookupProfile(profiles yaml.MapSlice, name string) (yaml.MapSlice, error) {
+	for _, item := range profiles {
+		if fmt.Sprint(item.Key) != name {
+			continue
+		}
+
+		switch v := item.Value.(type) {
+		case yaml.MapSlice:
+			return v, nil
+		case nil:
+			return yaml.MapSlice{}, nil
+		default:
+			return nil, fmt.Errorf("profiles.%s: expected a map of options", name)
+		}
+	}
+
+	names := make([]string, 0, len(profiles))
+
+	for _, item := range profiles {
+		names = append(names, fmt.Sprint(item.Key))
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("profile %q is not defined", name)
+	}
+
+	return nil, fmt.Errorf("profile %q is not defined (available: %s)", name, strings.Join(names, ", "))
+}
+
+// applyOptions sets the options of a config section. The prefix locates the
+// section in errors, e.g. "lab.yaml" or "lab.yaml: profiles.staging".
+func applyOptions(cmd *cli.Command, prefix string, options yaml.MapSlice, aliases map[string]string) error {
+	known := make(map[string]bool)
+
+	for _, flag := range RunFlags(true) {
+		known[flag.Names()[0]] = true
+	}
+
+	for _, item := range options {
+		key, ok := item.Key.(string)
+		if !ok {
+			return fmt.Errorf("%s: option name %v must be a string", prefix, item.Key)
+		}
+
+		if key == "config" || key == "profile" || !known[key] {
+			return fmt.Errorf("%s: unknown option %q", prefix, key)
+		}
+
+		name := key
+
+		if alias, found := aliases[key]; found {
+			name = alias
+		}
+
+		flag := lookupFlag(cmd, name)
+
+		// options of other commands are valid but do not apply
+		if flag == nil {
+			continue
+		}
+
+		values, err := configValues(name, flag, normalizeConfigValue(item.Value))
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", prefix, key, err)
+		}
+
+		if cmd.IsSet(name) {
+			if !mergedConfigFlags[name] {
+				continue
+			}
+
+			values = missingEntries(values, cmd.StringSlice(name))
+		}
+
+		if err := setFlagValues(cmd, name, values); err != nil {
+			return fmt.Errorf("%s: %s: %w", prefix, key, err)
+		}
+	}
+
+	return nil
+}
+
+// loadConfig reads the config file at the given path, or the default config
+// file when the path is empty. It returns no options when the default config
+// file does not exist.
+func loadConfig(path string) (string, yaml.MapSlice, error) {
+	explicit := path != ""
+
+	if !explicit {
+		path = configFileName
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return path, nil, nil
+		}
+
+		return path, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	options := yaml.MapSlice{}
+
+	if err := yaml.Unmarshal(content, &options); err != nil {
+		return path, nil, fmt.Errorf("%s: failed to parse config: %w", path, err)
+	}
+
+	return path, options, nil
+}
+
+func lookupFlag(cmd *cli.Command, name string) cli.Flag {
+	for _, flag := range cmd.Flags {
+		if flag.Names()[0] == name {
+			return flag
+		}
+	}
+
+	return nil
+}
+
+// configValues converts a config value into the values the flag would be
+// given on the command line.
+func configValues(name string, flag cli.Flag, value any) ([]string, error) {
+	if _, ok := flag.(*cli.StringSliceFlag); !ok {
+		if m, ok := value.(map[string]any); ok && name == "policy-http-default-headers" {
+			data, err := json.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+
+			return []string{string(data)}, nil
+		}
+
+		text, err := configScalar(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{text}, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return configEntries(name, v)
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			text, err := configScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("expected a list of values: %w", err)
+			}
+
+			values = append(values, text)
+		}
+
+		return values, nil
+	default:
+		text, err := configScalar(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{text}, nil
+	}
+}
+
+// configEntries converts a map into "<key>:<json>" param entries or
+// "<target>=<source>" binding entries.
+func configEntries(name string, values map[string]any) ([]string, error) {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		switch name {
+		case "param", "runtime-param", "secret", "runtime-secret":
+			data, err := json.Marshal(values[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			entries = append(entries, key+":"+string(data))
+		case "param-bind":
+			source, err := configScalar(values[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			entries = append(entries, key+"="+source)
+		default:
+			return nil, errors.New("expected a list of values, got a map")
+		}
+	}
+
+	return entries, nil
+}
+
+func configScalar(value any) (string, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return "", errors.New("expected a single value, got a map")
+	case []any:
+		return "", errors.New("expected a single value, got a list")
+	case nil:
+		return "", errors.New("expected a value")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// missingEntries returns the "<key>:<json>" entries whose key is not
+// assigned by any of the given entries.
+func missingEntries(entries []string, given []string) []string {
+	assigned := make(map[string]bool, len(given))
+
+	for _, entry := range given {
+		key, _, _ := strings.Cut(entry, ":")
+		assigned[key] = true
+	}
+
+	missing := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		key, _, _ := strings.Cut(entry, ":")
+
+		if !assigned[key] {
+			missing = append(missing, entry)
+		}
+	}
+
+	return missing
+}
+
+// setFlagValues sets the values one by one; config values are taken
+// literally, so a value containing a comma is not split like a command line
+// value would be.
+func setFlagValues(cmd *cli.Command, name string, values []string) error {
+	disabled := cmd.DisableSliceFlagSeparator
+	cmd.DisableSliceFlagSeparator = true
+
+	defer func() {
+		cmd.DisableSliceFlagSeparator = disabled
+	}()
+
+	for _, value := range values {
+		if err := cmd.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q: %w", value, err)
+		}
+	}
+
+	return nil
+}
+
+func normalizeConfigValue(value any) any {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		out := make(map[string]any, len(v))
+
+		for _, item := range v {
+			out[fmt.Sprint(item.Key)] = normalizeConfigValue(item.Value)
+		}
+
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalizeConfigValue(item)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			out[i] = normalizeConfigValue(item)
+		}
+
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func runWithConfig(t *testing.T, config string, args ...string) (*cli.Command, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "lab.yaml")
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(config)+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var applied *cli.Command
+
+	command := &cli.Command{
+		Name:  "run",
+		Flags: RunFlags(false),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			applied = cmd
+
+			return applyConfig(cmd, nil)
+		},
+	}
+
+	err := command.Run(context.Background(), append([]string{"run", "--config", path}, args...))
+
+	return applied, err
+}
+
+func TestApplyConfigSetsUnsetFlags(t *testing.T) {
+	cmd, err := runWithConfig(t, `
+files: [tests/]
+concurrency: 4
+fail-fast: true
+tag: smoke
+reporter:
+  - simple
+  - junit=out/results.xml
+param:
+  baseUrl: http://localhost:8080
+  options: {retries: 3, verbose: true}
+param-bind:
+  fixtures: "@lab.static.fixtures"
+policy-http-default-headers:
+  X-Test: lab
+`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cmd.Uint64("concurrency") != 4 || !cmd.Bool("fail-fast") {
+		t.Fatalf("expected scalar options to be set, got concurrency=%d fail-fast=%t", cmd.Uint64("concurrency"), cmd.Bool("fail-fast"))
+	}
+
+	for name, expected := range map[string][]string{
+		"files":      {"tests/"},
+		"tag":        {"smoke"},
+		"reporter":   {"simple", "junit=out/results.xml"},
+		"param":      {`baseUrl:"http://localhost:8080"`, `options:{"retries":3,"verbose":true}`},
+		"param-bind": {"fixtures=@lab.static.fixtures"},
+	} {
+		if actual := cmd.StringSlice(name); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %s to be %q, got %q", name, expected, actual)
+		}
+	}
+
+	if headers := cmd.String("policy-http-default-headers"); headers != `{"X-Test":"lab"}` {
+		t.Fatalf("expected default headers as JSON, got %s", headers)
+	}
+}
+
+func TestApplyConfigPrefersCommandLineAndEnvironment(t *testing.T) {
+	t.Setenv("LAB_TAG", "api")
+
+	cmd, err := runWithConfig(t, `
+concurrency: 4
+tag: [smoke]
+param:
+  baseUrl: http://localhost:8080
+  locale: en
+`, "--concurrency=2", `--param=baseUrl:"https://example.test"`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cmd.Uint64("concurrency") != 2 {
+		t.Fatalf("expected the command line to override the config, got %d", cmd.Uint64("concurrency"))
+	}
+
+	if tags := cmd.StringSlice("tag"); !reflect.DeepEqual(tags, []string{"api"}) {
+		t.Fatalf("expected the environment to override the config, got %q", tags)
+	}
+
+	params, err := toParams(cmd.StringSlice("param"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(params, map[string]any{"baseUrl": "https://example.test", "locale": "en"}) {
+		t.Fatalf("expected params to be merged by key, got %v", params)
+	}
+}
+
+func TestApplyConfigReportsOffendingKey(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{config: "concurency: 4", expected: `lab.yaml: unknown option "concurency"`},
+		{config: "concurrency: four", expected: `lab.yaml: concurrency: invalid value "four"`},
+		{config: "runtime: [a, b]", expected: "lab.yaml: runtime: expected a single value, got a list"},
+		{config: "tag: {smoke: true}", expected: "lab.yaml: tag: expected a list of values, got a map"},
+		{config: "config: other.yaml", expected: `lab.yaml: unknown option "config"`},
+	}
+
+	for _, test := range tests {
+		_, err := runWithConfig(t, test.config)
+
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("%s: expected error containing %q, got %v", test.config, test.expected, err)
+		}
+	}
+}
+
+func TestApplyConfigDiscoversConfigInWorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, configFileName), []byte("concurrency: 3\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var concurrency uint64
+
+	command := &cli.Command{
+		Name:  "run",
+		Flags: RunFlags(false),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if err := applyConfig(cmd, nil); err != nil {
+				return err
+			}
+
+			concurrency = cmd.Uint64("concurrency")
+
+			return nil
+		},
+	}
+
+	if err := command.Run(context.Background(), []string{"run"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if concurrency != 3 {
+		t.Fatalf("expected the discovered config to apply, got %d", concurrency)
+	}
+}
+
+func TestApplyConfigAppliesProfile(t *testing.T) {
+	config := `
+runtime: http://localhost:9090
+concurrency: 4
+param:
+  baseUrl: http://localhost:8080
+  locale: en
+profiles:
+  staging:
+    runtime: https://ferret.staging.example.com
+    param:
+      baseUrl: https://staging.example.com
+    policy-http-allowed-hosts: [staging.example.com]
+`
+
+	cmd, err := runWithConfig(t, config, "--profile=staging", "--concurrency=2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cmd.String("runtime") != "https://ferret.staging.example.com" {
+		t.Fatalf("expected the profile to override the top-level options, got %s", cmd.String("runtime"))
+	}
+
+	if cmd.Uint64("concurrency") != 2 {
+		t.Fatalf("expected the command line to override the config, got %d", cmd.Uint64("concurrency"))
+	}
+
+	if hosts := cmd.StringSlice("policy-http-allowed-hosts"); !reflect.DeepEqual(hosts, []string{"staging.example.com"}) {
+		t.Fatalf("expected the profile policy, got %q", hosts)
+	}
+
+	params, err := toParams(cmd.StringSlice("param"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(params, map[string]any{"baseUrl": "https://staging.example.com", "locale": "en"}) {
+		t.Fatalf("expected profile params to be merged by key, got %v", params)
+	}
+
+	cmd, err = runWithConfig(t, config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cmd.String("runtime") != "http://localhost:9090" {
+		t.Fatalf("expected the top-level options without a profile, got %s", cmd.String("runtime"))
+	}
+}
+
+func TestApplyConfigReportsProfileErrors(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{config: "profiles: {local: {}, ci: {}}", expected: `lab.yaml: profile "staging" is not defined (available: local, ci)`},
+		{config: "concurrency: 1", expected: `lab.yaml: profile "staging" is not defined`},
+		{config: "profiles: {staging: {concurency: 4}}", expected: `lab.yaml: profiles.staging: unknown option "concurency"`},
+		{config: "profiles: {staging: {timeout: soon}}", expected: `lab.yaml: profiles.staging: timeout: invalid value "soon"`},
+		{config: "profiles: {staging: [a]}", expected: "lab.yaml: profiles.staging: expected a map of options"},
+		{config: "profiles: [staging]", expected: "lab.yaml: profiles: expected a map of profiles"},
+	}
+
+	for _, test := range tests {
+		_, err := runWithConfig(t, test.config, "--profile=staging")
+
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("%s: expected error containing %q, got %v", test.config, test.expected, err)
+		}
+	}
+}
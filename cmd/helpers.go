@@ -50,6 +50,20 @@ func toParams(values []string) (map[string]any, error) {
 	return res, nil
 }
 
+// toMaxFailures combines --fail-fast with --max-failures; fail-fast is a
+// shorthand for a limit of one failure and cannot be mixed with another limit.
+func toMaxFailures(failFast bool, maxFailures uint64) (uint64, error) {
+	if !failFast {
+		return maxFailures, nil
+	}
+
+	if maxFailures > 1 {
+		return 0, fmt.Errorf("--fail-fast cannot be combined with --max-failures=%d", maxFailures)
+	}
+
+	return 1, nil
+}
+
 func createStaticServerManagerFromCommand(cmd *cli.Command, entries staticserver.ServeEntries) (*staticserver.Manager, error) {
 	if len(entries) == 0 {
 		return nil, nil
@@ -69,7 +83,10 @@ func createStaticServerManagerFromCommand(cmd *cli.Command, entries staticserver
 	return manager, nil
 }
 
-func createMockAPIServerManagerFromCommand(cmd *cli.Command, entries mockserver.Entries) (*localserver.Manager, error) {
+// createMockAPIServerManagerFromCommand creates the mock API servers of the
+// entries. The handlers are registered with the given handlers, if any, so
+// that they can be reloaded while the servers run.
+func createMockAPIServerManagerFromCommand(cmd *cli.Command, entries mockserver.Entries, handlers *mockHandlers) (*localserver.Manager, error) {
 	if len(entries) == 0 {
 		return nil, nil
 	}
@@ -84,6 +101,10 @@ func createMockAPIServerManagerFromCommand(cmd *cli.Command, entries mockserver.
 				return nil, err
 			}
 
+			if handlers != nil {
+				return handlers.add(entry.Path, server.Handler()), nil
+			}
+
 			return server.Handler(), nil
 		},
 		StartErrorLabel: "failed to start mock API server",
@@ -205,6 +226,17 @@ func showCurrentCommandHelp(cmd *cli.Command) error {
 	return nil
 }
 
+func appErrWriter(cmd *cli.Command) io.Writer {
+	if cmd != nil {
+		root := cmd.Root()
+		if root.ErrWriter != nil {
+			return root.ErrWriter
+		}
+	}
+
+	return os.Stderr
+}
+
 func appWriter(cmd *cli.Command) io.Writer {
 	if cmd != nil {
 		root := cmd.Root()
@@ -40,3 +40,34 @@ func TestExtractBinaryFlagsRejectsInvalidValues(t *testing.T) {
 		t.Fatalf("expected invalid flags to remain unchanged, got %#v", params)
 	}
 }
+
+func TestToMaxFailures(t *testing.T) {
+	tests := []struct {
+		failFast    bool
+		maxFailures uint64
+		expected    uint64
+		err         string
+	}{
+		{expected: 0},
+		{maxFailures: 3, expected: 3},
+		{failFast: true, expected: 1},
+		{failFast: true, maxFailures: 1, expected: 1},
+		{failFast: true, maxFailures: 3, err: "--fail-fast cannot be combined with --max-failures=3"},
+	}
+
+	for _, test := range tests {
+		got, err := toMaxFailures(test.failFast, test.maxFailures)
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("expected error %q, got %v", test.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil || got != test.expected {
+			t.Fatalf("expected %d, got %d (%v)", test.expected, got, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// envParamPrefix marks the variables of an env file that are user params;
+	// LAB_PARAM_baseUrl=... sets the "baseUrl" param.
+	envParamPrefix = "LAB_PARAM_"
+	// envSecretPrefix marks the variables of an env file that are secret
+	// user params, like --secret.
+	envSecretPrefix = "LAB_SECRET_"
+)
+
+// toFileParams reads the JSON or YAML params files and merges them in order:
+// a param of a later file replaces the param of the same name of an earlier
+// one.
+func toFileParams(paths []string) (map[string]any, error) {
+	res := make(map[string]any)
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read params file: %w", err)
+		}
+
+		var raw any
+
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse params file: %w", path, err)
+		}
+
+		if raw == nil {
+			continue
+		}
+
+		// values are passed through JSON so that they have the same types as
+		// the values given with --param
+		data, err := json.Marshal(normalizeConfigValue(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse params file: %w", path, err)
+		}
+
+		var params map[string]any
+
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("%s: expected a map of params", path)
+		}
+
+		for key, value := range params {
+			res[key] = value
+		}
+	}
+
+	return res, nil
+}
+
+// toEnvFileParams reads the dotenv files and turns their LAB_PARAM_* and
+// LAB_SECRET_* variables into user params and secret user params; other
+// variables are ignored. An unquoted value is parsed as JSON when it is valid
+// JSON and taken as a string otherwise, a quoted value is always a string.
+func toEnvFileParams(paths []string) (map[string]any, map[string]any, error) {
+	params := make(map[string]any)
+	secrets := make(map[string]any)
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		line := 0
+
+		for scanner.Scan() {
+			line++
+
+			name, value, quoted, err := parseEnvLine(scanner.Text())
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+
+			target := params
+			prefix := envParamPrefix
+			key, found := strings.CutPrefix(name, envParamPrefix)
+
+			if !found {
+				target = secrets
+				prefix = envSecretPrefix
+				key, found = strings.CutPrefix(name, envSecretPrefix)
+			}
+
+			if !found {
+				continue
+			}
+
+			if key == "" {
+				return nil, nil, fmt.Errorf("%s:%d: %s must be followed by a param name", path, line, prefix)
+			}
+
+			var param any = value
+
+			if !quoted {
+				var decoded any
+
+				if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+					param = decoded
+				}
+			}
+
+			target[key] = param
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+	}
+
+	return params, secrets, nil
+}
+
+// parseEnvLine parses a NAME=value line of a dotenv file. Blank lines and
+// comments yield an empty name.
+func parseEnvLine(text string) (string, string, bool, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" || strings.HasPrefix(text, "#") {
+		return "", "", false, nil
+	}
+
+	text = strings.TrimPrefix(text, "export ")
+
+	name, value, found := strings.Cut(text, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("expected NAME=value, got %q", text)
+	}
+
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+
+	if name == "" {
+		return "", "", false, fmt.Errorf("expected NAME=value, got %q", text)
+	}
+
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", "", false, fmt.Errorf("%s: unterminated quoted value", name)
+		}
+
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", "", false, fmt.Errorf("%s: invalid quoted value: %w", name, err)
+		}
+
+		return name, unquoted, true, nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", false, fmt.Errorf("%s: unterminated quoted value", name)
+		}
+
+		return name, value[1 : end+1], true, nil
+	}
+
+	// an unquoted value ends at an inline comment
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+
+	return name, value, false, nil
+}
+
+// closingQuote returns the index of the double quote closing the value, or
+// -1 when the value is not terminated.
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
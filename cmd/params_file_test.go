@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeParamsFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	return path
+}
+
+func TestToFileParamsMergesFilesInOrder(t *testing.T) {
+	base := writeParamsFile(t, "base.json", `{"baseUrl": "http://localhost", "limit": 3, "tags": ["a"]}`)
+	override := writeParamsFile(t, "override.yaml", "limit: 5\nuser:\n  name: lab\n")
+
+	params, err := toFileParams([]string{base, override})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]any{
+		"baseUrl": "http://localhost",
+		"limit":   float64(5),
+		"tags":    []any{"a"},
+		"user":    map[string]any{"name": "lab"},
+	}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expected %v, got %v", expected, params)
+	}
+}
+
+func TestToFileParamsRejectsInvalidFiles(t *testing.T) {
+	list := writeParamsFile(t, "list.yaml", "- a\n- b\n")
+	broken := writeParamsFile(t, "broken.json", `{"baseUrl": `)
+
+	for path, expected := range map[string]string{
+		list:              list + ": expected a map of params",
+		broken:            broken + ": failed to parse params file",
+		list + ".missing": "failed to read params file",
+	} {
+		_, err := toFileParams([]string{path})
+
+		if err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Fatalf("%s: expected error starting with %q, got %v", path, expected, err)
+		}
+	}
+}
+
+func TestToEnvFileParams(t *testing.T) {
+	path := writeParamsFile(t, ".env", `
+# comment
+LAB_PARAM_baseUrl=https://example.test # inline comment
+export LAB_PARAM_limit=3
+LAB_PARAM_enabled=true
+LAB_PARAM_id="42"
+LAB_PARAM_note='a # b'
+LAB_PARAM_filter={"active":true}
+LAB_PARAM_greeting="hello\nworld"
+LAB_SECRET_token=abc123
+LAB_TIMEOUT=10
+`)
+
+	params, secrets, err := toEnvFileParams([]string{path})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]any{
+		"baseUrl":  "https://example.test",
+		"limit":    float64(3),
+		"enabled":  true,
+		"id":       "42",
+		"note":     "a # b",
+		"filter":   map[string]any{"active": true},
+		"greeting": "hello\nworld",
+	}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expected %v, got %v", expected, params)
+	}
+
+	if !reflect.DeepEqual(secrets, map[string]any{"token": "abc123"}) {
+		t.Fatalf("expected LAB_SECRET_ variables as secrets, got %v", secrets)
+	}
+}
+
+func TestToEnvFileParamsReportsLine(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{content: "LAB_PARAM_a=1\nbroken", expected: `:2: expected NAME=value, got "broken"`},
+		{content: `LAB_PARAM_a="open`, expected: ":1: LAB_PARAM_a: unterminated quoted value"},
+		{content: "LAB_PARAM_=1", expected: ":1: LAB_PARAM_ must be followed by a param name"},
+		{content: "LAB_SECRET_=1", expected: ":1: LAB_SECRET_ must be followed by a param name"},
+	}
+
+	for _, test := range tests {
+		path := writeParamsFile(t, ".env", test.content)
+
+		_, _, err := toEnvFileParams([]string{path})
+		if err == nil || err.Error() != path+test.expected {
+			t.Fatalf("%q: expected error %q, got %v", test.content, path+test.expected, err)
+		}
+	}
+}
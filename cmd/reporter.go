@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+)
+
+// reporterSpec is a parsed --reporter value. An empty output writes to stdout.
+type reporterSpec struct {
+	Name   string
+	Output string
+}
+
+func toReporterSpecs(values []string, output string) ([]reporterSpec, error) {
+	specs := make([]reporterSpec, 0, len(values)+1)
+
+	for _, value := range values {
+		name, path, found := strings.Cut(value, "=")
+		name = strings.TrimSpace(name)
+		path = strings.TrimSpace(path)
+
+		if found && path == "" {
+			return nil, fmt.Errorf("invalid reporter %q: expected <name> or <name>=<path>", value)
+		}
+
+		if _, err := reporters.Lookup(name); err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			name = "console"
+		}
+
+		specs = append(specs, reporterSpec{Name: name, Output: path})
+	}
+
+	if len(specs) == 0 {
+		specs = append(specs, reporterSpec{Name: "console"})
+	}
+
+	output = strings.TrimSpace(output)
+
+	if output != "" {
+		if len(specs) != 1 || specs[0].Output != "" {
+			return nil, fmt.Errorf("--reporter-output requires a single --reporter without an output path; use <name>=<path> instead")
+		}
+
+		if specs[0].Name == "console" {
+			specs[0].Output = output
+		} else {
+			// the selected reporter goes to the file while progress stays visible on stdout
+			specs = []reporterSpec{
+				{Name: "console"},
+				{Name: specs[0].Name, Output: output},
+			}
+		}
+	}
+
+	stdout := ""
+	outputs := make(map[string]string, len(specs))
+
+	for _, spec := range specs {
+		if spec.Output == "" {
+			if stdout != "" {
+				return nil, fmt.Errorf("reporters %q and %q both write to stdout; use <name>=<path> for one of them", stdout, spec.Name)
+			}
+
+			stdout = spec.Name
+
+			continue
+		}
+
+		path := filepath.Clean(spec.Output)
+
+		if existing, found := outputs[path]; found {
+			return nil, fmt.Errorf("reporters %q and %q both write to %q", existing, spec.Name, spec.Output)
+		}
+
+		outputs[path] = spec.Name
+	}
+
+	return specs, nil
+}
+
+// reporterFromCommand creates the configured reporters and fans the run stream
+// out to all of them. The returned function closes the reporter output files.
+func reporterFromCommand(cmd *cli.Command) (reporters.Reporter, func() error, error) {
+	specs, err := toReporterSpecs(cmd.StringSlice("reporter"), cmd.String("reporter-output"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]*os.File, 0, len(specs))
+	closeFiles := func() error {
+		errs := make([]error, 0, len(files))
+
+		for _, file := range files {
+			errs = append(errs, file.Close())
+		}
+
+		return errors.Join(errs...)
+	}
+
+	list := make([]reporters.Reporter, 0, len(specs))
+
+	for _, spec := range specs {
+		var out io.Writer = appWriter(cmd)
+
+		if spec.Output != "" {
+			file, err := createReporterOutput(spec.Output)
+			if err != nil {
+				_ = closeFiles()
+
+				return nil, nil, err
+			}
+
+			files = append(files, file)
+			out = file
+		}
+
+		reporter, err := reporters.New(spec.Name, out)
+		if err != nil {
+			_ = closeFiles()
+
+			return nil, nil, err
+		}
+
+		list = append(list, reporter)
+	}
+
+	if len(list) == 1 {
+		return list[0], closeFiles, nil
+	}
+
+	return reporters.NewMulti(list...), closeFiles, nil
+}
+
+func createReporterOutput(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create reporter output directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create reporter output: %w", err)
+	}
+
+	return file, nil
+}
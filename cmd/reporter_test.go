@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToReporterSpecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		output   string
+		expected []reporterSpec
+	}{
+		{
+			name:     "default",
+			expected: []reporterSpec{{Name: "console"}},
+		},
+		{
+			name:     "stdout and files",
+			values:   []string{"console", "junit=out/junit.xml", " simple = out/simple.txt "},
+			expected: []reporterSpec{{Name: "console"}, {Name: "junit", Output: "out/junit.xml"}, {Name: "simple", Output: "out/simple.txt"}},
+		},
+		{
+			name:     "legacy output keeps console on stdout",
+			values:   []string{"junit"},
+			output:   "junit.xml",
+			expected: []reporterSpec{{Name: "console"}, {Name: "junit", Output: "junit.xml"}},
+		},
+		{
+			name:     "legacy output for console",
+			values:   []string{"console"},
+			output:   "console.log",
+			expected: []reporterSpec{{Name: "console", Output: "console.log"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := toReporterSpecs(tt.values, tt.output)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(specs, tt.expected) {
+				t.Fatalf("expected %#v, got %#v", tt.expected, specs)
+			}
+		})
+	}
+}
+
+func TestToReporterSpecsRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		output   string
+		expected string
+	}{
+		{name: "unknown reporter", values: []string{"bogus=out.txt"}, expected: "unknown reporter: bogus"},
+		{name: "empty path", values: []string{"junit="}, expected: "expected <name> or <name>=<path>"},
+		{name: "two stdout reporters", values: []string{"console", "simple"}, expected: `reporters "console" and "simple" both write to stdout`},
+		{name: "same file", values: []string{"junit=out/a.xml", "simple=out/../out/a.xml"}, expected: `reporters "junit" and "simple" both write to`},
+		{name: "output with many reporters", values: []string{"console", "junit=a.xml"}, output: "b.xml", expected: "--reporter-output requires a single --reporter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := toReporterSpecs(tt.values, tt.output)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -3,16 +3,17 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-waitfor/waitfor"
 	http "github.com/go-waitfor/waitfor-http"
 	"github.com/urfave/cli/v3"
 
-	"github.com/MontFerret/lab/v2/pkg/reporters"
 	"github.com/MontFerret/lab/v2/pkg/runner"
 	"github.com/MontFerret/lab/v2/pkg/sources"
 	"github.com/MontFerret/lab/v2/pkg/testing"
+	"github.com/MontFerret/lab/v2/pkg/watcher"
 )
 
 func RunCommand() *cli.Command {
@@ -42,11 +43,17 @@ func RunFlags(hidden bool) []cli.Flag {
 			Value:   30,
 			Hidden:  hidden,
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:    "reporter",
-			Usage:   "reporter (console, simple)",
+			Usage:   "reporter with an optional output file, can be repeated (console, simple, junit, json, tap, html, github; --reporter=console --reporter=json=out/results.json)",
 			Sources: cli.EnvVars("LAB_REPORTER"),
-			Value:   "console",
+			Value:   []string{"console"},
+			Hidden:  hidden,
+		},
+		&cli.StringFlag{
+			Name:    "reporter-output",
+			Usage:   "write the output of a single reporter to a file while console output still goes to stdout",
+			Sources: cli.EnvVars("LAB_REPORTER_OUTPUT"),
 			Hidden:  hidden,
 		},
 		&cli.StringFlag{
@@ -63,6 +70,12 @@ func RunFlags(hidden bool) []cli.Flag {
 			Sources: cli.EnvVars("LAB_RUNTIME_PARAM"),
 			Hidden:  hidden,
 		},
+		&cli.StringSliceFlag{
+			Name:    "runtime-secret",
+			Usage:   "secret runtime adapter parameter whose value is masked in all output, merged over --runtime-param (--runtime-secret=headers:{\"Authorization\": \"Bearer abc\"})",
+			Sources: cli.EnvVars("LAB_RUNTIME_SECRET"),
+			Hidden:  hidden,
+		},
 		&cli.Uint64Flag{
 			Name:    "concurrency",
 			Aliases: []string{"c"},
@@ -93,6 +106,49 @@ func RunFlags(hidden bool) []cli.Flag {
 			Value:   0,
 			Hidden:  hidden,
 		},
+		&cli.StringSliceFlag{
+			Name:    "tag",
+			Usage:   "run only YAML suites declaring at least one of the tags, others are reported as skipped (--tag=smoke --tag=api)",
+			Sources: cli.EnvVars("LAB_TAG"),
+			Hidden:  hidden,
+		},
+		&cli.StringSliceFlag{
+			Name:    "exclude-tag",
+			Usage:   "skip YAML suites declaring any of the tags, takes precedence over --tag (--exclude-tag=slow)",
+			Sources: cli.EnvVars("LAB_EXCLUDE_TAG"),
+			Hidden:  hidden,
+		},
+		&cli.BoolFlag{
+			Name:    "fail-fast",
+			Usage:   "stop the run after the first failed test, same as --max-failures=1",
+			Sources: cli.EnvVars("LAB_FAIL_FAST"),
+			Hidden:  hidden,
+		},
+		&cli.Uint64Flag{
+			Name:    "max-failures",
+			Usage:   "stop the run after N failed tests, cancelling running tests and reporting the rest as cancelled (0 means no limit)",
+			Sources: cli.EnvVars("LAB_MAX_FAILURES"),
+			Value:   0,
+			Hidden:  hidden,
+		},
+		&cli.StringFlag{
+			Name:    "shard",
+			Usage:   "run only the i-th of n deterministic shards of the discovered files (--shard=2/4)",
+			Sources: cli.EnvVars("LAB_SHARD"),
+			Hidden:  hidden,
+		},
+		&cli.StringFlag{
+			Name:    "shard-timings",
+			Usage:   "json reporter output of a previous run used to balance shards by duration",
+			Sources: cli.EnvVars("LAB_SHARD_TIMINGS"),
+			Hidden:  hidden,
+		},
+		&cli.BoolFlag{
+			Name:    "update-snapshots",
+			Usage:   "rewrite suite snapshots with the current output instead of comparing against them (filesystem sources only)",
+			Sources: cli.EnvVars("LAB_UPDATE_SNAPSHOTS"),
+			Hidden:  hidden,
+		},
 		&cli.StringSliceFlag{
 			Name:    "serve",
 			Usage:   "serve a local directory over HTTP during test execution (<path>, <path>:<port>, <path>@<alias>, <path>@<alias>:<port>)",
@@ -124,12 +180,36 @@ func RunFlags(hidden bool) []cli.Flag {
 			Sources: cli.EnvVars("LAB_PARAM"),
 			Hidden:  hidden,
 		},
+		&cli.StringSliceFlag{
+			Name:    "secret",
+			Usage:   "secret query parameter whose value is masked in all output (--secret=token:\"abc\")",
+			Sources: cli.EnvVars("LAB_SECRET"),
+			Hidden:  hidden,
+		},
 		&cli.StringSliceFlag{
 			Name:    "param-bind",
 			Usage:   "bind a query parameter path to an existing parameter (<target>=@<source>, --param-bind baseUrl=@lab.static.fixtures)",
 			Sources: cli.EnvVars("LAB_PARAM_BIND"),
 			Hidden:  hidden,
 		},
+		&cli.StringSliceFlag{
+			Name:    "params-file",
+			Usage:   "JSON or YAML file of query parameters, merged in order before --env-file and --param (--params-file=fixtures.json)",
+			Sources: cli.EnvVars("LAB_PARAMS_FILE"),
+			Hidden:  hidden,
+		},
+		&cli.StringSliceFlag{
+			Name:    "secrets-file",
+			Usage:   "JSON or YAML file of secret query parameters, like --params-file with values masked in all output (--secrets-file=credentials.yaml)",
+			Sources: cli.EnvVars("LAB_SECRETS_FILE"),
+			Hidden:  hidden,
+		},
+		&cli.StringSliceFlag{
+			Name:    "env-file",
+			Usage:   "dotenv file whose LAB_PARAM_<name> and LAB_SECRET_<name> variables are query and secret query parameters, merged before --param (--env-file=.env)",
+			Sources: cli.EnvVars("LAB_ENV_FILE"),
+			Hidden:  hidden,
+		},
 		&cli.StringSliceFlag{
 			Name:    "wait",
 			Aliases: []string{"w"},
@@ -152,14 +232,32 @@ func RunFlags(hidden bool) []cli.Flag {
 			Value:   5,
 			Hidden:  hidden,
 		},
+		&cli.BoolFlag{
+			Name:    "watch",
+			Usage:   "keep running and re-run the tests affected by changes of local files until interrupted",
+			Sources: cli.EnvVars("LAB_WATCH"),
+			Hidden:  hidden,
+		},
+		&cli.DurationFlag{
+			Name:    "watch-interval",
+			Usage:   "interval between two scans for changed files in watch mode",
+			Sources: cli.EnvVars("LAB_WATCH_INTERVAL"),
+			Value:   watcher.DefaultInterval,
+			Hidden:  hidden,
+		},
 	}
 
+	flags = append(flags, configFlags(hidden)...)
 	flags = append(flags, fsPolicyFlags(hidden)...)
 
 	return append(flags, httpPolicyFlags(hidden)...)
 }
 
 func RunAction(ctx context.Context, cmd *cli.Command) error {
+	if err := applyConfig(cmd, nil); err != nil {
+		return cli.Exit(err, 1)
+	}
+
 	locations, ok := locationsFromCommand(cmd)
 
 	if !ok {
@@ -177,12 +275,59 @@ func runScripts(ctx context.Context, cmd *cli.Command, locations []string) (runE
 	params := testing.NewParams()
 	paramValues := cmd.StringSlice("param")
 
-	userParams, err := toParams(paramValues)
+	userParams, err := toFileParams(cmd.StringSlice("params-file"))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fileSecrets, err := toFileParams(cmd.StringSlice("secrets-file"))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	envParams, envSecrets, err := toEnvFileParams(cmd.StringSlice("env-file"))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	inlineParams, err := toParams(paramValues)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	secretValues := cmd.StringSlice("secret")
+
+	inlineSecrets, err := toSecretParams(secretValues)
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
 
-	paramBindings, err := toParamBindings(cmd.StringSlice("param-bind"), paramValues)
+	runtimeSecrets, err := toSecretParams(cmd.StringSlice("runtime-secret"))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	// inline params win over env files, which win over params files; secrets
+	// win over params of the same source
+	for _, values := range []map[string]any{fileSecrets, envParams, envSecrets, inlineParams, inlineSecrets} {
+		for key, value := range values {
+			userParams[key] = value
+		}
+	}
+
+	secrets := slices.Concat(
+		secretStrings(fileSecrets),
+		secretStrings(envSecrets),
+		secretStrings(inlineSecrets),
+		secretStrings(runtimeSecrets),
+	)
+	redactor := runner.NewRedactor(secrets)
+
+	defer func() {
+		runErr = redactExitError(redactor, runErr)
+	}()
+
+	paramBindings, err := toParamBindings(cmd.StringSlice("param-bind"), slices.Concat(paramValues, secretValues))
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
@@ -208,12 +353,25 @@ func runScripts(ctx context.Context, cmd *cli.Command, locations []string) (runE
 		}
 	}
 
+	var roots []string
+
+	if cmd.Bool("watch") {
+		roots, err = watchRoots(locations)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+	}
+
 	runtimeParams, err := toParams(cmd.StringSlice("runtime-param"))
 
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
 
+	for key, value := range runtimeSecrets {
+		runtimeParams[key] = value
+	}
+
 	rt, err := newRuntime(cmd, runtimeParams)
 
 	if err != nil {
@@ -226,13 +384,30 @@ func runScripts(ctx context.Context, cmd *cli.Command, locations []string) (runE
 		}
 	}()
 
+	maxFailures, err := toMaxFailures(cmd.Bool("fail-fast"), cmd.Uint64("max-failures"))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	shard, err := shardFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
 	r, err := runner.New(runner.Options{
-		Runtime:       rt,
-		PoolSize:      cmd.Uint64("concurrency"),
-		Attempts:      cmd.Uint64("attempts"),
-		TestTimeout:   time.Duration(cmd.Uint64("timeout")) * time.Second,
-		Times:         cmd.Uint64("times"),
-		TimesInterval: cmd.Uint64("times-interval"),
+		Runtime:         rt,
+		PoolSize:        cmd.Uint64("concurrency"),
+		Attempts:        cmd.Uint64("attempts"),
+		TestTimeout:     time.Duration(cmd.Uint64("timeout")) * time.Second,
+		Times:           cmd.Uint64("times"),
+		TimesInterval:   cmd.Uint64("times-interval"),
+		Tags:            cmd.StringSlice("tag"),
+		ExcludeTags:     cmd.StringSlice("exclude-tag"),
+		MaxFailures:     maxFailures,
+		Shard:           shard,
+		UpdateSnapshots: cmd.Bool("update-snapshots"),
+		Profile:         cmd.String("profile"),
+		Secrets:         secrets,
 	})
 
 	if err != nil {
@@ -282,7 +457,13 @@ func runScripts(ctx context.Context, cmd *cli.Command, locations []string) (runE
 		}
 	}
 
-	mockManager, err := createMockAPIServerManagerFromCommand(cmd, mockAPIEntries)
+	var mocks *mockHandlers
+
+	if cmd.Bool("watch") {
+		mocks = newMockHandlers()
+	}
+
+	mockManager, err := createMockAPIServerManagerFromCommand(cmd, mockAPIEntries, mocks)
 	if err != nil {
 		return cli.Exit(err.Error(), 1)
 	}
@@ -307,12 +488,38 @@ func runScripts(ctx context.Context, cmd *cli.Command, locations []string) (runE
 		return cli.Exit(err, 1)
 	}
 
-	stream := r.Run(runner.NewContext(ctx, params), src)
+	if cmd.Bool("watch") {
+		recorder := sources.NewRecorder()
+		session := &watchSession{
+			cmd:      cmd,
+			runner:   r,
+			params:   params,
+			source:   recorder.Wrap(src),
+			recorder: recorder,
+			roots:    roots,
+			static:   servedDirs(serveEntries),
+			mocks:    mocks,
+		}
+
+		if err := session.watch(ctx); err != nil {
+			return cli.Exit(err, 1)
+		}
 
-	reporter, err := reporters.New(cmd.String("reporter"), appWriter(cmd))
+		return nil
+	}
+
+	reporter, closeReporter, err := reporterFromCommand(cmd)
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
 
+	defer func() {
+		if closeErr := closeReporter(); runErr == nil && closeErr != nil {
+			runErr = cli.Exit(closeErr, 1)
+		}
+	}()
+
+	stream := r.Run(runner.NewContext(ctx, params), src)
+
 	return reporter.Report(ctx, stream)
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+// toSecretParams parses --secret values like toParams does, without
+// repeating the values in errors.
+func toSecretParams(values []string) (map[string]any, error) {
+	res := make(map[string]any)
+
+	for _, entry := range values {
+		key, text, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid secret param: expected <name>:<value>")
+		}
+
+		var value any
+
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON for secret param %q", key)
+		}
+
+		res[key] = value
+	}
+
+	return res, nil
+}
+
+// secretStrings returns the values to redact for the secret params: every
+// string nested in the values and the JSON form of every list and map. Bare
+// numbers and booleans are not masked, since their text, such as "1" or
+// "true", would mask unrelated output.
+func secretStrings(params map[string]any) []string {
+	values := make([]string, 0, len(params))
+
+	var collect func(value any)
+
+	collect = func(value any) {
+		switch v := value.(type) {
+		case string:
+			values = append(values, v)
+
+			return
+		case []any:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]any:
+			for _, item := range v {
+				collect(item)
+			}
+		default:
+			return
+		}
+
+		if data, err := json.Marshal(value); err == nil {
+			values = append(values, string(data))
+		}
+	}
+
+	for _, value := range params {
+		collect(value)
+	}
+
+	return values
+}
+
+// redactExitError masks the secrets of an error returned by a command while
+// keeping its exit code.
+func redactExitError(redactor *runner.Redactor, err error) error {
+	redacted := redactor.Error(err)
+
+	if redacted == err {
+		return err
+	}
+
+	if exitErr, ok := err.(cli.ExitCoder); ok {
+		return cli.Exit(redacted, exitErr.ExitCode())
+	}
+
+	return redacted
+}
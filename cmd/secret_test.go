@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestToSecretParamsDoesNotRepeatValues(t *testing.T) {
+	params, err := toSecretParams([]string{`token:"abc"`, `auth:{"user":"lab","password":"xyz"}`})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	values := secretStrings(params)
+	sort.Strings(values)
+
+	if !reflect.DeepEqual(values, []string{"abc", "lab", "xyz", `{"password":"xyz","user":"lab"}`}) {
+		t.Fatalf("expected the nested secret strings and the JSON map, got %q", values)
+	}
+
+	for _, entry := range []string{"token=abc", "token:abc"} {
+		_, err := toSecretParams([]string{entry})
+
+		if err == nil || strings.Contains(err.Error(), "abc") {
+			t.Fatalf("%s: expected an error without the value, got %v", entry, err)
+		}
+	}
+}
+
+func TestSecretStringsSkipsNumbersAndBooleans(t *testing.T) {
+	params, err := toSecretParams([]string{`retries:1`, `debug:true`, `headers:{"X-Debug":true,"X-Token":"t0ken"}`})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	redactor := runner.NewRedactor(secretStrings(params))
+
+	for text, expected := range map[string]string{
+		"passed=1 failed=0 duration=1.1s":            "passed=1 failed=0 duration=1.1s",
+		"retry: true":                                "retry: true",
+		"token t0ken rejected":                       "token *** rejected",
+		`headers {"X-Debug":true,"X-Token":"t0ken"}`: "headers ***",
+	} {
+		if actual := redactor.String(text); actual != expected {
+			t.Fatalf("expected %q to be redacted to %q, got %q", text, expected, actual)
+		}
+	}
+}
+
+func TestRedactExitErrorKeepsExitCode(t *testing.T) {
+	redactor := runner.NewRedactor([]string{"abc"})
+
+	err := redactExitError(redactor, cli.Exit(errors.New("runtime rejected token abc"), 3))
+
+	var exitErr cli.ExitCoder
+
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+
+	if err.Error() != "runtime rejected token ***" {
+		t.Fatalf("expected a redacted message, got %q", err.Error())
+	}
+}
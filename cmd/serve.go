@@ -14,7 +14,7 @@ func ServeCommand() *cli.Command {
 		Name:      "serve",
 		Usage:     "Serve one or more local HTTP services",
 		UsageText: "lab serve [options]",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringSliceFlag{
 				Name:    "static",
 				Usage:   "served directory mapping (<path>, <path>:<port>, <path>@<alias>, <path>@<alias>:<port>)",
@@ -35,7 +35,7 @@ func ServeCommand() *cli.Command {
 				Usage:   "host to advertise for local server URLs (host only, no port)",
 				Sources: cli.EnvVars("LAB_SERVE_HOST"),
 			},
-		},
+		}, configFlags(false)...),
 		Action: ServeAction,
 	}
 }
@@ -45,6 +45,11 @@ func ServeAction(ctx context.Context, cmd *cli.Command) error {
 		return cli.Exit("serve entries must use --static or --mock", 1)
 	}
 
+	// the directories 'lab run' serves are the static directories of 'lab serve'
+	if err := applyConfig(cmd, map[string]string{"serve": "static"}); err != nil {
+		return cli.Exit(err, 1)
+	}
+
 	staticValues := cmd.StringSlice("static")
 	mockAPIValues := cmd.StringSlice("mock")
 
@@ -71,7 +76,7 @@ func ServeAction(ctx context.Context, cmd *cli.Command) error {
 		return cli.Exit(err.Error(), 1)
 	}
 
-	mockManager, err := createMockAPIServerManagerFromCommand(cmd, mockAPIEntries)
+	mockManager, err := createMockAPIServerManagerFromCommand(cmd, mockAPIEntries, nil)
 	if err != nil {
 		return cli.Exit(err.Error(), 1)
 	}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+type shardTimingEvent struct {
+	Schema     string  `json:"schema"`
+	Type       string  `json:"type"`
+	File       string  `json:"file"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+func shardFromCommand(cmd *cli.Command) (*runner.Shard, error) {
+	value := cmd.String("shard")
+	timingsPath := cmd.String("shard-timings")
+
+	if value == "" {
+		if timingsPath != "" {
+			return nil, errors.New("--shard-timings requires --shard")
+		}
+
+		return nil, nil
+	}
+
+	index, count, err := runner.ParseShard(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var timings map[string]time.Duration
+
+	if timingsPath != "" {
+		file, err := os.Open(timingsPath)
+		if err != nil {
+			return nil, fmt.Errorf("open shard timings: %w", err)
+		}
+
+		timings, err = readShardTimings(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("read shard timings %s: %w", timingsPath, err)
+		}
+	}
+
+	return runner.NewShard(index, count, timings)
+}
+
+// readShardTimings reads result durations from the output of the json
+// reporter, keyed by the file or case name of the result; the shard sums up
+// the durations of the cases of a file. Summary events are ignored.
+func readShardTimings(r io.Reader) (map[string]time.Duration, error) {
+	timings := make(map[string]time.Duration)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var evt shardTimingEvent
+
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if evt.Schema != reporters.JSONSchemaVersion {
+			return nil, fmt.Errorf("line %d: unsupported schema %q, expected %q", line, evt.Schema, reporters.JSONSchemaVersion)
+		}
+
+		if evt.Type != "result" || evt.File == "" {
+			continue
+		}
+
+		timings[evt.File] = time.Duration(evt.DurationMs * float64(time.Millisecond))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return timings, nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadShardTimings(t *testing.T) {
+	input := strings.Join([]string{
+		`{"schema":"lab.report/v1","type":"result","file":"a.fql","status":"passed","attempts":1,"times":1,"durationMs":1500}`,
+		``,
+		`{"schema":"lab.report/v1","type":"result","file":"b.yaml","status":"failed","attempts":1,"times":1,"durationMs":20.5}`,
+		`{"schema":"lab.report/v1","type":"summary","passed":1,"failed":1,"durationMs":1600}`,
+	}, "\n")
+
+	timings, err := readShardTimings(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(timings) != 2 || timings["a.fql"] != 1500*time.Millisecond || timings["b.yaml"] != 20500*time.Microsecond {
+		t.Fatalf("unexpected timings: %v", timings)
+	}
+}
+
+func TestReadShardTimingsRejectsInvalidInput(t *testing.T) {
+	tests := map[string]string{
+		"not json":       "line 1: invalid character",
+		`{"schema":"x"}`: `line 1: unsupported schema "x"`,
+	}
+
+	for input, expected := range tests {
+		_, err := readShardTimings(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,523 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/mockserver"
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	"github.com/MontFerret/lab/v2/pkg/staticserver"
+	"github.com/MontFerret/lab/v2/pkg/testing"
+	"github.com/MontFerret/lab/v2/pkg/watcher"
+)
+
+type (
+	// watchSession re-runs the tests of a source affected by file changes.
+	// The runner, its runtime and the local servers are shared by all runs.
+	watchSession struct {
+		cmd      *cli.Command
+		runner   *runner.Runner
+		params   testing.Params
+		source   sources.Source
+		recorder *sources.Recorder
+		roots    []string
+		static   []string
+		mocks    *mockHandlers
+		// files are the files of the last read of the source, in read order.
+		files []sources.File
+	}
+
+	// mockHandlers keeps the handlers of the mock API servers by spec path so
+	// that a changed spec can be reloaded while its server keeps running.
+	mockHandlers struct {
+		mu       sync.Mutex
+		handlers map[string]*mockHandler
+	}
+
+	mockHandler struct {
+		current atomic.Pointer[http.Handler]
+	}
+
+	// fileList is a source of files that were already read.
+	fileList struct {
+		files  []sources.File
+		errors []sources.Error
+	}
+
+	dependentSuite interface {
+		DependsOn() []string
+	}
+)
+
+const clearScreen = "\033[H\033[2J"
+
+// watchRoots returns the local paths of the locations; watch mode supports
+// only filesystem sources.
+func watchRoots(locations []string) ([]string, error) {
+	roots := make([]string, 0, len(locations))
+
+	for _, location := range locations {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+
+		if u.Scheme != "" && sources.GetType(u) != sources.SourceTypeFS {
+			return nil, fmt.Errorf("--watch supports only local files and directories, got %s", location)
+		}
+
+		root, err := filepath.Abs(filepath.Join(u.Host, u.Path))
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, root)
+	}
+
+	return roots, nil
+}
+
+// servedDirs returns the absolute paths of the served directories.
+func servedDirs(entries staticserver.ServeEntries) []string {
+	dirs := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		dir, err := filepath.Abs(entry.Path)
+		if err != nil {
+			continue
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+func newMockHandlers() *mockHandlers {
+	return &mockHandlers{handlers: make(map[string]*mockHandler)}
+}
+
+func (m *mockHandlers) add(path string, handler http.Handler) http.Handler {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	h := &mockHandler{}
+	h.current.Store(&handler)
+	m.handlers[path] = h
+
+	return h
+}
+
+func (m *mockHandlers) paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paths := make([]string, 0, len(m.handlers))
+
+	for path := range m.handlers {
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// reload rebuilds the handlers of the changed specs. A spec that fails to
+// load keeps its previous handler.
+func (m *mockHandlers) reload(changed map[string]bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	errs := make([]error, 0)
+
+	for path, h := range m.handlers {
+		if !changed[path] {
+			continue
+		}
+
+		server, err := mockserver.New(mockserver.Options{SpecPath: path})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to reload mock API spec %s: %w", path, err))
+
+			continue
+		}
+
+		handler := server.Handler()
+		h.current.Store(&handler)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *mockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, r)
+}
+
+func (l fileList) Read(_ context.Context) (<-chan sources.File, <-chan sources.Error) {
+	onNext := make(chan sources.File, len(l.files))
+	onError := make(chan sources.Error, len(l.errors))
+
+	for _, file := range l.files {
+		onNext <- file
+	}
+
+	for _, err := range l.errors {
+		onError <- err
+	}
+
+	close(onNext)
+	close(onError)
+
+	return onNext, onError
+}
+
+func (l fileList) Resolve(ctx context.Context, _ *url.URL) (<-chan sources.File, <-chan sources.Error) {
+	return sources.NewNoop().Read(ctx)
+}
+
+// watch runs every test once and then the tests affected by each change
+// until the context is cancelled.
+func (s *watchSession) watch(ctx context.Context) error {
+	w := watcher.New(watcher.Options{Interval: s.cmd.Duration("watch-interval")})
+
+	// the baseline of a run is taken before it starts, by this scan and then
+	// by the wait that preceded it, so edits made while it runs are changes
+	w.Scan(s.paths())
+
+	var changed []string
+
+	for {
+		watched := s.paths()
+
+		if err := s.run(ctx, changed); err != nil {
+			return err
+		}
+
+		// files written by the run itself, e.g. updated snapshots, and the
+		// files the run referenced for the first time are not changes
+		w.Track(append(s.recorder.Written(), added(watched, s.paths())...))
+
+		var err error
+
+		changed, err = w.Wait(ctx, s.paths)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// added returns the paths of current that are not in previous.
+func added(previous, current []string) []string {
+	known := make(map[string]bool, len(previous))
+
+	for _, path := range previous {
+		known[path] = true
+	}
+
+	res := make([]string, 0)
+
+	for _, path := range current {
+		if !known[path] {
+			res = append(res, path)
+		}
+	}
+
+	return res
+}
+
+// paths returns the files and directories to watch: the locations, the
+// served directories and mock specs, the directory configs and every file
+// referenced by the files of the last read.
+func (s *watchSession) paths() []string {
+	paths := append(append([]string{}, s.roots...), s.static...)
+
+	if s.mocks != nil {
+		paths = append(paths, s.mocks.paths()...)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, file := range s.files {
+		for i, owner := range fileOwners(file) {
+			if seen[owner] {
+				continue
+			}
+
+			seen[owner] = true
+
+			// configs of the directories above a location are outside of it
+			if i > 0 {
+				paths = append(paths, owner)
+			}
+
+			paths = append(paths, s.recorder.Refs(owner)...)
+		}
+	}
+
+	return paths
+}
+
+// run reads the source and runs the tests affected by the changed files, or
+// every test when changed is nil.
+func (s *watchSession) run(ctx context.Context, changed []string) error {
+	out := appWriter(s.cmd)
+
+	changes := make(map[string]bool, len(changed))
+
+	for _, name := range changed {
+		changes[name] = true
+	}
+
+	if s.mocks != nil {
+		if err := s.mocks.reload(changes); err != nil {
+			fmt.Fprintln(appErrWriter(s.cmd), err)
+		}
+	}
+
+	list := s.read(ctx)
+	selected := s.affected(changed, changes)
+
+	if changed != nil && len(selected) == 0 && len(list.errors) == 0 {
+		fmt.Fprintf(out, "Changed %s; no tests affected\n", s.describe(changed))
+
+		return nil
+	}
+
+	files := make([]sources.File, 0, len(selected))
+
+	for _, file := range list.files {
+		if selected[file.Name] {
+			s.recorder.Reset(file.Name)
+			files = append(files, file)
+		}
+	}
+
+	if f, ok := out.(*os.File); ok && isTerminal(f) {
+		fmt.Fprint(out, clearScreen)
+	}
+
+	if changed == nil {
+		fmt.Fprintf(out, "Running %d tests\n", len(files))
+	} else {
+		fmt.Fprintf(out, "Changed %s; running %d of %d tests\n", s.describe(changed), len(files), len(list.files))
+	}
+
+	reporter, closeReporter, err := reporterFromCommand(s.cmd)
+	if err != nil {
+		return err
+	}
+
+	stream := s.runner.Run(runner.NewContext(ctx, s.params), fileList{files: files, errors: list.errors})
+	err = reporter.Report(ctx, stream)
+
+	if closeErr := closeReporter(); err == nil {
+		err = closeErr
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, reporters.ErrHasErrors) {
+		return err
+	}
+
+	fmt.Fprintln(out, "Watching for changes; press Ctrl+C to stop")
+
+	return nil
+}
+
+// read reads every file of the source.
+func (s *watchSession) read(ctx context.Context) fileList {
+	list := fileList{}
+	onNext, onError := s.source.Read(ctx)
+
+	for onNext != nil || onError != nil {
+		select {
+		case <-ctx.Done():
+			return list
+		case file, ok := <-onNext:
+			if !ok {
+				onNext = nil
+
+				continue
+			}
+
+			list.files = append(list.files, file)
+		case err, ok := <-onError:
+			if !ok {
+				onError = nil
+
+				continue
+			}
+
+			list.errors = append(list.errors, err)
+		}
+	}
+
+	s.files = list.files
+
+	return list
+}
+
+// affected returns the names of the files to run: the changed files, the
+// files referencing a changed file directly or through their directory
+// configs, the files depending on these, and the files all of them depend
+// on. Every file is affected when changed is nil or a served file or mock
+// spec changed.
+func (s *watchSession) affected(changed []string, changes map[string]bool) map[string]bool {
+	selected := make(map[string]bool, len(s.files))
+	all := changed == nil
+
+	for _, name := range changed {
+		for _, dir := range s.static {
+			if name == dir || strings.HasPrefix(name, dir+string(filepath.Separator)) {
+				all = true
+			}
+		}
+	}
+
+	if s.mocks != nil {
+		for _, path := range s.mocks.paths() {
+			if changes[path] {
+				all = true
+			}
+		}
+	}
+
+	for _, file := range s.files {
+		if all {
+			selected[file.Name] = true
+
+			continue
+		}
+
+		for _, owner := range fileOwners(file) {
+			if changes[owner] {
+				selected[file.Name] = true
+			}
+
+			for _, ref := range s.recorder.Refs(owner) {
+				if changes[ref] {
+					selected[file.Name] = true
+				}
+			}
+		}
+	}
+
+	deps := s.dependencies()
+	dependents := make(map[string][]string, len(deps))
+
+	for name, names := range deps {
+		for _, dep := range names {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	// dependents exercise the affected files as well, and dependencies have
+	// to run again for their dependents to start
+	follow(selected, dependents)
+	follow(selected, deps)
+
+	return selected
+}
+
+// follow adds every file reachable from the selected files through the edges
+// to the selection.
+func follow(selected map[string]bool, edges map[string][]string) {
+	pending := make([]string, 0, len(selected))
+
+	for name := range selected {
+		pending = append(pending, name)
+	}
+
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for _, next := range edges[name] {
+			if !selected[next] {
+				selected[next] = true
+				pending = append(pending, next)
+			}
+		}
+	}
+}
+
+// dependencies returns the files each file of the last read depends on.
+func (s *watchSession) dependencies() map[string][]string {
+	deps := make(map[string][]string)
+
+	for _, file := range s.files {
+		testCase, err := testing.New(testing.Options{File: file})
+		if err != nil {
+			continue
+		}
+
+		if dependent, ok := testCase.(dependentSuite); ok {
+			deps[file.Name] = dependent.DependsOn()
+		}
+	}
+
+	return deps
+}
+
+// describe lists the changed files relative to the working directory.
+func (s *watchSession) describe(changed []string) string {
+	wd, _ := os.Getwd()
+	names := make([]string, 0, len(changed))
+
+	for _, name := range changed {
+		if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+
+		names = append(names, name)
+	}
+
+	const limit = 3
+
+	if len(names) > limit {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:limit], ", "), len(names)-limit)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// fileOwners returns the file and its directory configs, which record the
+// files resolved on their behalf.
+func fileOwners(file sources.File) []string {
+	owners := make([]string, 0, len(file.Configs)+1)
+	owners = append(owners, file.Name)
+
+	for _, config := range file.Configs {
+		owners = append(owners, config.Name)
+	}
+
+	return owners
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+	"github.com/urfave/cli/v3"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	labtesting "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestWatchSessionSelectsAffectedTests(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"tests/login.yaml":     "query:\n  text: RETURN 1\nassert:\n  text: RETURN true\n",
+		"tests/checkout.yaml":  "dependsOn: [login.yaml]\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n",
+		"tests/api/_lab.yaml":  "params:\n  retries: 3\n",
+		"tests/api/users.fql":  "RETURN 1",
+		"tests/standalone.fql": "RETURN 1",
+		"public/index.html":    "<html></html>",
+	} {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	u, err := url.Parse(filepath.Join(dir, "tests"))
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+
+	src, err := sources.NewFileSystem(u)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	recorder := sources.NewRecorder()
+	session := &watchSession{
+		source:   recorder.Wrap(src),
+		recorder: recorder,
+		static:   []string{filepath.Join(dir, "public")},
+	}
+
+	if files := session.read(context.Background()).files; len(files) != 4 {
+		t.Fatalf("expected four tests, got %d", len(files))
+	}
+
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+
+	tests := []struct {
+		changed  string
+		expected []string
+	}{
+		{changed: "tests/standalone.fql", expected: []string{"tests/standalone.fql"}},
+		{changed: "tests/checkout.yaml", expected: []string{"tests/checkout.yaml", "tests/login.yaml"}},
+		{changed: "tests/login.yaml", expected: []string{"tests/checkout.yaml", "tests/login.yaml"}},
+		{changed: "tests/api/_lab.yaml", expected: []string{"tests/api/users.fql"}},
+		{changed: "tests/unrelated.txt", expected: []string{}},
+		{changed: "public/index.html", expected: []string{"tests/api/users.fql", "tests/checkout.yaml", "tests/login.yaml", "tests/standalone.fql"}},
+	}
+
+	for _, test := range tests {
+		changed := []string{path(test.changed)}
+		selected := session.affected(changed, map[string]bool{changed[0]: true})
+
+		actual := make([]string, 0, len(selected))
+
+		for name := range selected {
+			rel, _ := filepath.Rel(dir, name)
+			actual = append(actual, filepath.ToSlash(rel))
+		}
+
+		sort.Strings(actual)
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.changed, test.expected, actual)
+		}
+	}
+}
+
+func TestWatchSessionWatchesParentConfigs(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"_lab.yaml":      "params:\n  retries: 3\n",
+		"tests/page.fql": "RETURN 1",
+	} {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	t.Chdir(dir)
+
+	root := filepath.Join(dir, "tests")
+
+	u, err := url.Parse(root)
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+
+	src, err := sources.NewFileSystem(u)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	recorder := sources.NewRecorder()
+	session := &watchSession{
+		source:   recorder.Wrap(src),
+		recorder: recorder,
+		roots:    []string{root},
+	}
+
+	session.read(context.Background())
+
+	config := filepath.Join(dir, "_lab.yaml")
+
+	if paths := session.paths(); !slices.Contains(paths, config) {
+		t.Fatalf("expected %s to be watched, got %v", config, paths)
+	}
+}
+
+func TestWatchSessionReportsEditsMadeDuringARun(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"tests/page.yaml": "query:\n  text: RETURN 1\nexpect:\n  snapshot: true\n",
+		"tests/edit.fql":  "RETURN 2",
+	} {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	t.Chdir(dir)
+
+	root := filepath.Join(dir, "tests")
+	edited := filepath.Join(root, "edit.fql")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the first run edits a test, the run of the edited test stops watching
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		switch query.Content() {
+		case "RETURN 2":
+			if err := os.WriteFile(edited, []byte("RETURN 3"), 0o644); err != nil {
+				return nil, err
+			}
+		case "RETURN 3":
+			cancel()
+		}
+
+		return []byte("1"), nil
+	})
+
+	r, err := runner.New(runner.Options{Runtime: rt, UpdateSnapshots: true})
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+
+	src, err := sources.NewFileSystem(&url.URL{Path: root})
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	command := &cli.Command{
+		Name:   "run",
+		Flags:  RunFlags(false),
+		Writer: out,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			recorder := sources.NewRecorder()
+			session := &watchSession{
+				cmd:      cmd,
+				runner:   r,
+				params:   labtesting.NewParams(),
+				source:   recorder.Wrap(src),
+				recorder: recorder,
+				roots:    []string{root},
+			}
+
+			return session.watch(ctx)
+		},
+	}
+
+	if err := command.Run(ctx, []string{"run", "--watch-interval=5ms", "--reporter=simple"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("expected the edit made during the run to be reported, got output:\n%s", out)
+	}
+
+	// the snapshot written by the first run is not a change
+	expected := "Changed " + filepath.Join("tests", "edit.fql") + "; running 1 of 2 tests"
+
+	if !strings.Contains(out.String(), expected) {
+		t.Fatalf("expected output to contain %q, got:\n%s", expected, out)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "page.snap.json")); err != nil {
+		t.Fatalf("expected the snapshot to be written: %v", err)
+	}
+}
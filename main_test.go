@@ -642,6 +642,66 @@ RETURN T::EQ(@baseUrl, "https://example.test")
 	assertEqual(t, stderr, "")
 }
 
+func TestRunCommandLoadsParamsAndEnvFiles(t *testing.T) {
+	script := writeNamedScript(t, "file_params.fql", `
+RETURN T::EQ(@baseUrl, "https://cli.example.test")
+  AND T::EQ(@config.api.limit, 5)
+  AND T::EQ(@locale, "de")
+  AND T::EQ(@token, "secret")
+`)
+	base := writeNamedScript(t, "base.json", `{"baseUrl": "https://file.example.test", "config": {"api": {"limit": 3}}, "locale": "en"}`)
+	override := writeNamedScript(t, "override.yaml", "config:\n  api:\n    limit: 5\n")
+	env := writeNamedScript(t, ".env", "# secrets\nLAB_PARAM_token=secret\nLAB_PARAM_locale=de\nOTHER=ignored\n")
+
+	stdout, stderr, err := runCLI(
+		t,
+		"run",
+		"--params-file", base,
+		"--params-file", override,
+		"--env-file", env,
+		`--param=baseUrl:"https://cli.example.test"`,
+		script,
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	assertContains(t, stdout, "Passed")
+	assertContains(t, stdout, "Done")
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandRedactsSecrets(t *testing.T) {
+	script := writeNamedScript(t, "secret.fql", `RETURN T::EQ("s3cr3t-value", @token)`)
+	env := writeNamedScript(t, ".env", "LAB_SECRET_apiKey=other-s3cr3t\n")
+	secrets := writeNamedScript(t, "secrets.yaml", "pin: \"90210\"\n")
+
+	for _, reporter := range []string{"console", "simple", "json", "tap"} {
+		stdout, stderr, err := runCLI(
+			t,
+			"run",
+			"--reporter="+reporter,
+			`--secret=token:"s3cr3t-value"`,
+			"--env-file", env,
+			"--secrets-file", secrets,
+			`--runtime-param=headers:{"X-Api-Key":"other-s3cr3t"}`,
+			`--runtime-secret=headers:{"Authorization":"Bearer runtime-s3cr3t"}`,
+			script,
+		)
+
+		assertErrorMessage(t, err, "has errors")
+
+		if strings.Contains(stdout+stderr, "s3cr3t") || strings.Contains(stdout+stderr, "90210") {
+			t.Fatalf("%s: expected secrets to be redacted\nstdout:\n%s\nstderr:\n%s", reporter, stdout, stderr)
+		}
+	}
+
+	_, _, err := runCLI(t, "run", "--secret=token:s3cr3t-value", script)
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("expected an invalid secret error without the value, got %v", err)
+	}
+}
+
 func TestRunCommandSupportsParamBindFromEnv(t *testing.T) {
 	script := writeNamedScript(t, "bound_env.fql", `RETURN T::EQ(@baseUrl, "https://env.example.test")`)
 
@@ -1090,6 +1150,322 @@ func TestRunCommandUsesSimpleReporter(t *testing.T) {
 	assertEqual(t, stderr, "")
 }
 
+func TestRunCommandWritesJUnitReporterOutput(t *testing.T) {
+	script := writeScript(t)
+	output := filepath.Join(t.TempDir(), "reports", "junit.xml")
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=junit", "--reporter-output", output, script)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected junit report, got %v", err)
+	}
+
+	assertContains(t, string(report), `<testsuites name="lab" tests="1" failures="0"`)
+	assertContains(t, string(report), `<testcase name="`+script+`"`)
+	assertContains(t, stdout, "Passed")
+	assertContains(t, stdout, "Done")
+	assertNotContains(t, stdout, "<testsuites")
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandFansOutToMultipleReporters(t *testing.T) {
+	script := writeScript(t)
+	dir := t.TempDir()
+	junitOutput := filepath.Join(dir, "out", "junit.xml")
+	simpleOutput := filepath.Join(dir, "out", "simple.txt")
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=console", "--reporter=junit="+junitOutput, "--reporter", "simple="+simpleOutput, script)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	junitReport, err := os.ReadFile(junitOutput)
+	if err != nil {
+		t.Fatalf("expected junit report, got %v", err)
+	}
+
+	simpleReport, err := os.ReadFile(simpleOutput)
+	if err != nil {
+		t.Fatalf("expected simple report, got %v", err)
+	}
+
+	assertContains(t, string(junitReport), `<testcase name="`+script+`"`)
+	assertContains(t, string(simpleReport), "DONE passed=1 failed=0")
+	assertContains(t, stdout, "Passed")
+	assertNotContains(t, stdout, "PASS file=")
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandFansOutFailuresToExitCode(t *testing.T) {
+	script := writeNamedScript(t, "test.fql", "RETURN NONE()")
+	output := filepath.Join(t.TempDir(), "junit.xml")
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=simple", "--reporter=junit="+output, script)
+
+	assertErrorMessage(t, err, "has errors")
+	assertContains(t, stdout, "FAIL file=")
+
+	report, readErr := os.ReadFile(output)
+	if readErr != nil {
+		t.Fatalf("expected junit report, got %v", readErr)
+	}
+
+	assertContains(t, string(report), "<failure")
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandRejectsMultipleStdoutReporters(t *testing.T) {
+	script := writeScript(t)
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=console", "--reporter=simple", script)
+
+	assertExitCode(t, err, 1)
+	assertErrorMessage(t, err, `reporters "console" and "simple" both write to stdout; use <name>=<path> for one of them`)
+	assertEqual(t, stdout, "")
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandUsesJSONReporter(t *testing.T) {
+	script := writeScript(t)
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=json", script)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected result and summary events, got %q", stdout)
+	}
+
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("expected NDJSON output, got line %q", line)
+		}
+	}
+
+	assertContains(t, lines[0], `"type":"result"`)
+	assertContains(t, lines[0], `"status":"passed"`)
+	assertContains(t, lines[1], `"type":"summary"`)
+	assertEqual(t, stderr, "")
+}
+
+func TestRunCommandReportsTagFilteredSuitesAsSkipped(t *testing.T) {
+	suite := writeNamedScript(t, "slow.yaml", `
+tags: [slow]
+query:
+  text: RETURN NONE()
+assert:
+  text: RETURN NONE()
+`)
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=simple", "--exclude-tag=slow", suite)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	assertContains(t, stdout, `SKIP file=`)
+	assertContains(t, stdout, `reason="excluded by tag \"slow\""`)
+	assertContains(t, stdout, "DONE passed=0 failed=0 skipped=1")
+
+	stdout, _, err = runCLI(t, "run", "--reporter=simple", "--tag=smoke", writeScript(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s", err, stdout)
+	}
+
+	assertContains(t, stdout, `reason="not tagged with \"smoke\""`)
+}
+
+func TestRunCommandFailFastCancelsRemainingFiles(t *testing.T) {
+	broken := writeNamedScript(t, "broken.fql", "RETURN NONE()")
+	pending := writeScript(t)
+
+	stdout, _, err := runCLI(t, "run", "--reporter=simple", "--fail-fast", broken, pending)
+
+	assertErrorMessage(t, err, "has errors")
+	assertContains(t, stdout, `FAIL file="`+broken+`"`)
+	assertContains(t, stdout, `CANCEL file="`+pending+`" reason="cancelled after reaching the failure limit (1)"`)
+	assertContains(t, stdout, "DONE passed=0 failed=1 cancelled=1")
+}
+
+func TestRunCommandRejectsFailFastWithMaxFailures(t *testing.T) {
+	_, _, err := runCLI(t, "run", "--fail-fast", "--max-failures=3", writeScript(t))
+
+	assertExitCode(t, err, 1)
+	assertErrorMessage(t, err, "--fail-fast cannot be combined with --max-failures=3")
+}
+
+func TestRunCommandRunsSingleShard(t *testing.T) {
+	first := writeScript(t)
+	second := writeScript(t)
+	assigned := 0
+
+	for _, shard := range []string{"1/2", "2/2"} {
+		stdout, stderr, err := runCLI(t, "run", "--reporter=simple", "--shard="+shard, first, second)
+		if err != nil {
+			t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+		}
+
+		assertContains(t, stdout, "SHARD index="+strings.Replace(shard, "/", " count=", 1))
+		assertContains(t, stdout, "discovered=2")
+		assigned += strings.Count(stdout, "PASS file=")
+	}
+
+	if assigned != 2 {
+		t.Fatalf("expected both files to run exactly once across shards, got %d", assigned)
+	}
+}
+
+func TestRunCommandRejectsInvalidShard(t *testing.T) {
+	_, _, err := runCLI(t, "run", "--shard=3/2", writeScript(t))
+
+	assertExitCode(t, err, 1)
+	assertErrorMessage(t, err, `invalid shard "3/2": index must be between 1 and 2`)
+}
+
+func TestRunCommandUpdatesSnapshots(t *testing.T) {
+	suite := writeNamedScript(t, "snapshot.yaml", `
+query:
+  text: RETURN 1
+expect:
+  snapshot: true
+`)
+
+	stdout, _, err := runCLI(t, "run", "--reporter=simple", suite)
+
+	assertErrorMessage(t, err, "has errors")
+	assertContains(t, stdout, "--update-snapshots to create it")
+
+	stdout, stderr, err := runCLI(t, "run", "--reporter=simple", "--update-snapshots", suite)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	content, readErr := os.ReadFile(filepath.Join(filepath.Dir(suite), "snapshot.snap.json"))
+	if readErr != nil {
+		t.Fatalf("expected snapshot to be written: %v", readErr)
+	}
+
+	assertEqual(t, string(content), "1\n")
+
+	stdout, stderr, err = runCLI(t, "run", "--reporter=simple", suite)
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	assertContains(t, stdout, "DONE passed=1 failed=0")
+}
+
+func TestRunCommandUsesProjectConfig(t *testing.T) {
+	script := writeScript(t)
+	config := writeNamedScript(t, "lab.yaml", fmt.Sprintf(`
+files:
+  - %q
+reporter: json
+`, script))
+
+	stdout, stderr, err := runCLI(t, "run", "--config", config, "--reporter=simple")
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	assertContains(t, stdout, "DONE passed=1 failed=0")
+
+	_, _, err = runCLI(t, "run", "--config", filepath.Join(filepath.Dir(config), "missing.yaml"))
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to read config") {
+		t.Fatalf("expected a missing config error, got %v", err)
+	}
+}
+
+func TestRunCommandUsesConfigProfile(t *testing.T) {
+	script := writeScript(t)
+	config := writeNamedScript(t, "lab.yaml", fmt.Sprintf(`
+files:
+  - %q
+reporter: json
+profiles:
+  ci:
+    reporter: simple
+`, script))
+
+	stdout, stderr, err := runCLI(t, "run", "--config", config, "--profile", "ci")
+	if err != nil {
+		t.Fatalf("expected no error, got %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+
+	assertContains(t, stdout, `PROFILE name="ci"`)
+	assertContains(t, stdout, "DONE passed=1 failed=0")
+
+	_, _, err = runCLI(t, "run", "--config", config, "--profile", "staging")
+	assertErrorMessage(t, err, config+`: profile "staging" is not defined (available: ci)`)
+}
+
+func TestRunCommandWatchRerunsAffectedTests(t *testing.T) {
+	root := t.TempDir()
+	testsDir := filepath.Join(root, "tests")
+	sharedDir := filepath.Join(root, "shared")
+	mustMkdir(t, testsDir)
+	mustMkdir(t, sharedDir)
+	mustWriteFile(t, filepath.Join(testsDir, "a.fql"), "RETURN 1")
+	mustWriteFile(t, filepath.Join(testsDir, "page.yaml"), "query:\n  ref: ../shared/page.fql\nassert:\n  text: RETURN true\n")
+	mustWriteFile(t, filepath.Join(sharedDir, "page.fql"), "RETURN 1")
+
+	stdout, stderr, done, cancel := startCLI(t, "run", "--watch", "--watch-interval=20ms", "--reporter=simple", testsDir)
+	defer cancel()
+
+	waitForOutput(t, stdout, "Watching for changes", 1)
+	assertContains(t, stdout.String(), "Running 2 tests")
+	assertContains(t, stdout.String(), "DONE passed=2 failed=0")
+
+	mustWriteFile(t, filepath.Join(sharedDir, "page.fql"), "RETURN 22")
+
+	waitForOutput(t, stdout, "Watching for changes", 2)
+	assertContains(t, stdout.String(), "running 1 of 2 tests")
+	assertContains(t, stdout.String(), "DONE passed=1 failed=0")
+
+	mustWriteFile(t, filepath.Join(testsDir, "b.fql"), "RETURN 2")
+
+	waitForOutput(t, stdout, "Watching for changes", 3)
+	assertContains(t, stdout.String(), "running 1 of 3 tests")
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v\nstderr:\n%s", err, stderr.String())
+	}
+}
+
+func TestRunCommandWatchRejectsRemoteLocations(t *testing.T) {
+	_, _, err := runCLI(t, "run", "--watch", "https://example.com/tests/")
+	assertErrorMessage(t, err, "--watch supports only local files and directories, got https://example.com/tests/")
+}
+
+func TestRunCommandUsesGitHubReporter(t *testing.T) {
+	script := writeNamedScript(t, "test.fql", "RETURN NONE()")
+	summaryPath := filepath.Join(t.TempDir(), "step-summary.md")
+
+	stdout, stderr, err := runCLIWithEnv(t, map[string]string{
+		"GITHUB_STEP_SUMMARY": summaryPath,
+	}, "run", "--reporter=github", script)
+
+	assertErrorMessage(t, err, "has errors")
+	assertContains(t, stdout, "::error file=")
+	assertContains(t, stdout, "DONE passed=0 failed=1")
+
+	content, readErr := os.ReadFile(summaryPath)
+	if readErr != nil {
+		t.Fatalf("expected step summary, got %v", readErr)
+	}
+
+	assertContains(t, string(content), "### Lab test results")
+	assertContains(t, string(content), "❌ failed")
+	assertEqual(t, stderr, "")
+}
+
 func TestRunCommandRejectsUnknownReporter(t *testing.T) {
 	script := writeScript(t)
 
@@ -1569,6 +1945,22 @@ paths:
 `
 }
 
+func waitForOutput(t *testing.T, stdout *safeBuffer, text string, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if strings.Count(stdout.String(), text) >= count {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("expected %q %d times in output, got:\n%s", text, count, stdout.String())
+}
+
 func waitForServeURL(t *testing.T, stdout *safeBuffer, alias string) string {
 	t.Helper()
 
@@ -2,7 +2,7 @@ package reporters
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"io"
 
 	"github.com/hako/durafmt"
@@ -27,6 +27,15 @@ func (c *Console) Report(ctx context.Context, stream runner.Stream) error {
 			c.logger.Warn().Str("File", res.Filename).Msg(res.Warning)
 		}
 
+		if res.Skipped {
+			c.logger.Info().
+				Str("File", res.Filename).
+				Str("Reason", res.SkipReason).
+				Msg(skippedMessage(res))
+
+			continue
+		}
+
 		var evt *zerolog.Event
 
 		if res.Error != nil {
@@ -60,16 +69,37 @@ func (c *Console) Report(ctx context.Context, stream runner.Stream) error {
 			event = c.logger.Error()
 		}
 
+		if sum.Profile != "" {
+			event = event.Str("Profile", sum.Profile)
+		}
+
+		if sum.Shard != nil {
+			event = event.
+				Str("Shard", fmt.Sprintf("%d/%d", sum.Shard.Index, sum.Shard.Count)).
+				Int("Assigned", sum.Shard.Assigned).
+				Int("Discovered", sum.Shard.Discovered)
+		}
+
 		event.
 			Int("Passed", sum.Passed).
 			Int("Failed", sum.Failed).
+			Int("Skipped", sum.Skipped).
+			Int("Cancelled", sum.Cancelled).
 			Str("Duration", durafmt.ParseShort(sum.Duration).InternationalString()).
 			Msg("Done")
 
 		if sum.HasErrors() {
-			return errors.New("has errors")
+			return ErrHasErrors
 		}
 
 		return nil
 	}
 }
+
+func skippedMessage(res runner.Result) string {
+	if res.Cancelled {
+		return "Cancelled"
+	}
+
+	return "Skipped"
+}
@@ -0,0 +1,199 @@
+package reporters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+// GitHubStepSummaryEnv names the file GitHub Actions renders as the job summary.
+const GitHubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+type (
+	// GitHub writes GitHub Actions workflow commands to annotate failed tests
+	// and deprecation warnings, and appends a Markdown results table to the job
+	// summary file when one is configured.
+	GitHub struct {
+		out         io.Writer
+		summaryPath string
+		root        string
+	}
+
+	githubRow struct {
+		file     string
+		status   string
+		attempts uint64
+		times    uint64
+		duration time.Duration
+		err      string
+	}
+)
+
+func NewGitHub(out io.Writer, summaryPath string) *GitHub {
+	root, _ := os.Getwd()
+
+	return &GitHub{
+		out:         out,
+		summaryPath: summaryPath,
+		root:        root,
+	}
+}
+
+func (g *GitHub) Report(ctx context.Context, stream runner.Stream) error {
+	rows := make([]githubRow, 0)
+
+	for res := range stream.Progress {
+		file := g.relative(res.Filename)
+		duration := res.Duration.Round(time.Millisecond)
+
+		// annotations attach to the file, so drop the "#case" suffix of suite cases
+		path, _, _ := strings.Cut(file, "#")
+
+		if res.Warning != "" {
+			fmt.Fprintf(g.out, "::warning file=%s,title=%s::%s\n", githubProperty(path), githubProperty("Test warning"), githubData(res.Warning))
+		}
+
+		row := githubRow{
+			file:     file,
+			status:   "✅ passed",
+			attempts: res.Attempts,
+			times:    res.Times,
+			duration: duration,
+		}
+
+		switch {
+		case res.Cancelled:
+			row.status = "⏹️ cancelled"
+			row.err = res.SkipReason
+
+			fmt.Fprintf(g.out, "CANCEL %s (%s)\n", file, res.SkipReason)
+		case res.Skipped:
+			row.status = "⏭️ skipped"
+			row.err = res.SkipReason
+
+			fmt.Fprintf(g.out, "SKIP %s (%s)\n", file, res.SkipReason)
+		case res.Error != nil:
+			row.status = "❌ failed"
+			row.err = res.Error.Error()
+
+			fmt.Fprintf(g.out, "FAIL %s (%s)\n", file, duration)
+			fmt.Fprintf(g.out, "::error file=%s,title=%s::%s\n", githubProperty(path), githubProperty("Test failed: "+file), githubData(row.err))
+		default:
+			fmt.Fprintf(g.out, "PASS %s (%s)\n", file, duration)
+		}
+
+		rows = append(rows, row)
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case sum := <-stream.Summary:
+		fmt.Fprintf(g.out, "DONE passed=%d failed=%d duration=%s\n", sum.Passed, sum.Failed, sum.Duration.Round(time.Millisecond))
+
+		if err := g.writeSummary(sum, rows); err != nil {
+			return err
+		}
+
+		if sum.HasErrors() {
+			return ErrHasErrors
+		}
+
+		return nil
+	}
+}
+
+func (g *GitHub) writeSummary(sum runner.Summary, rows []githubRow) error {
+	if g.summaryPath == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("### Lab test results\n\n")
+	buf.WriteString("| Passed | Failed | Skipped | Cancelled | Duration |\n")
+	buf.WriteString("| ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&buf, "| %d | %d | %d | %d | %s |\n\n", sum.Passed, sum.Failed, sum.Skipped, sum.Cancelled, sum.Duration.Round(time.Millisecond))
+
+	if sum.Profile != "" {
+		fmt.Fprintf(&buf, "Profile: %s.\n\n", markdownCell(sum.Profile))
+	}
+
+	if sum.Shard != nil {
+		fmt.Fprintf(&buf, "%s.\n\n", shardDescription(sum.Shard))
+	}
+
+	if len(rows) > 0 {
+		buf.WriteString("| File | Status | Attempts | Times | Duration | Details |\n")
+		buf.WriteString("| --- | --- | ---: | ---: | ---: | --- |\n")
+
+		for _, row := range rows {
+			fmt.Fprintf(&buf, "| %s | %s | %d | %d | %s | %s |\n",
+				markdownCell(row.file), row.status, row.attempts, row.times, row.duration, markdownCell(row.err))
+		}
+
+		buf.WriteString("\n")
+	}
+
+	file, err := os.OpenFile(g.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open github step summary: %w", err)
+	}
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("write github step summary: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write github step summary: %w", err)
+	}
+
+	return nil
+}
+
+// relative converts absolute paths under the working directory into the
+// relative form GitHub uses to attach annotations to repository files.
+func (g *GitHub) relative(name string) string {
+	if g.root == "" || !filepath.IsAbs(name) {
+		return name
+	}
+
+	rel, err := filepath.Rel(g.root, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return name
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// githubData escapes a workflow command message.
+func githubData(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
+
+// githubProperty escapes a workflow command property value.
+func githubProperty(value string) string {
+	value = githubData(value)
+	value = strings.ReplaceAll(value, ":", "%3A")
+
+	return strings.ReplaceAll(value, ",", "%2C")
+}
+
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
@@ -0,0 +1,111 @@
+package reporters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestGitHubReporterWritesAnnotationsAndStepSummary(t *testing.T) {
+	const warning = "'.fail.fql' expected-failure tests are deprecated; use a YAML test with 'expect.error' instead"
+
+	progress := make(chan runner.Result, 3)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "tests/legacy.fail.fql", Duration: time.Millisecond, Warning: warning}
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 2,
+		Filename: "tests/a,b:c.yaml",
+		Duration: 12 * time.Millisecond,
+		Error:    errors.New("100% wrong\nexpected | true"),
+	}
+	progress <- runner.Result{Filename: "tests/slow.yaml", Skipped: true, SkipReason: `excluded by tag "slow"`}
+	close(progress)
+	summary <- runner.Summary{Passed: 1, Failed: 1, Skipped: 1, Duration: time.Second}
+	close(summary)
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(summaryPath, []byte("existing\n"), 0o644); err != nil {
+		t.Fatalf("failed to write summary: %v", err)
+	}
+
+	var out bytes.Buffer
+	err := reporters.NewGitHub(&out, summaryPath).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) {
+		t.Fatalf("expected has errors, got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"::warning file=tests/legacy.fail.fql,title=Test warning::" + warning,
+		"PASS tests/legacy.fail.fql (1ms)",
+		"FAIL tests/a,b:c.yaml (12ms)",
+		"::error file=tests/a%2Cb%3Ac.yaml,title=Test failed%3A tests/a%2Cb%3Ac.yaml::100%25 wrong%0Aexpected | true",
+		`SKIP tests/slow.yaml (excluded by tag "slow")`,
+		"DONE passed=1 failed=1 duration=1s",
+		"",
+	}, "\n")
+
+	if out.String() != expected {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+
+	content, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+
+	for _, line := range []string{
+		"existing",
+		"### Lab test results",
+		"| 1 | 1 | 1 | 0 | 1s |",
+		"| tests/legacy.fail.fql | ✅ passed | 1 | 1 | 1ms |  |",
+		"| tests/a,b:c.yaml | ❌ failed | 2 | 1 | 12ms | 100% wrong<br>expected \\| true |",
+		`| tests/slow.yaml | ⏭️ skipped | 0 | 0 | 0s | excluded by tag "slow" |`,
+	} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Fatalf("expected summary line %q, got:\n%s", line, content)
+		}
+	}
+}
+
+func TestGitHubReporterSkipsStepSummaryWithoutPath(t *testing.T) {
+	progress := make(chan runner.Result)
+	summary := make(chan runner.Summary, 1)
+	close(progress)
+	summary <- runner.Summary{}
+	close(summary)
+
+	var out bytes.Buffer
+	if err := reporters.NewGitHub(&out, "").Report(context.Background(), runner.Stream{Progress: progress, Summary: summary}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if out.String() != "DONE passed=0 failed=0 duration=0s\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestGitHubReporterAnnotatesSuiteCasesOnTheirFile(t *testing.T) {
+	progress := make(chan runner.Result, 1)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Filename: "tests/page.yaml#title", Error: errors.New("boom")}
+	close(progress)
+	summary <- runner.Summary{Failed: 1}
+	close(summary)
+
+	var out bytes.Buffer
+	_ = reporters.NewGitHub(&out, "").Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+
+	expected := "::error file=tests/page.yaml,title=Test failed%3A tests/page.yaml#title::boom\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Fatalf("expected %q, got:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,196 @@
+package reporters
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+const htmlHistogramBuckets = 10
+
+//go:embed html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateText))
+
+type (
+	// HTML writes a single self-contained HTML page once the summary arrives.
+	// The page embeds its styles and scripts so it can be archived as is.
+	HTML struct {
+		out io.Writer
+	}
+
+	htmlReport struct {
+		Title       string
+		GeneratedAt string
+		Duration    string
+		Profile     string
+		Shard       string
+		Total       int
+		Passed      int
+		Failed      int
+		Skipped     int
+		Cancelled   int
+		Histogram   []htmlBucket
+		Results     []htmlResult
+	}
+
+	htmlResult struct {
+		Index      int
+		File       string
+		Status     string
+		Attempts   uint64
+		Times      uint64
+		Duration   string
+		DurationNs int64
+		Error      string
+		Warning    string
+		Reason     string
+	}
+
+	htmlBucket struct {
+		Label   string
+		Count   int
+		Percent int
+	}
+)
+
+func NewHTML(out io.Writer) *HTML {
+	return &HTML{out: out}
+}
+
+func (h *HTML) Report(ctx context.Context, stream runner.Stream) error {
+	generatedAt := time.Now()
+	results := make([]htmlResult, 0)
+
+	for res := range stream.Progress {
+		result := htmlResult{
+			Index:      len(results) + 1,
+			File:       res.Filename,
+			Status:     "passed",
+			Attempts:   res.Attempts,
+			Times:      res.Times,
+			Duration:   res.Duration.Round(time.Millisecond).String(),
+			DurationNs: res.Duration.Nanoseconds(),
+			Warning:    res.Warning,
+		}
+
+		switch {
+		case res.Cancelled:
+			result.Status = "cancelled"
+			result.Reason = res.SkipReason
+		case res.Skipped:
+			result.Status = "skipped"
+			result.Reason = res.SkipReason
+		case res.Error != nil:
+			result.Status = "failed"
+			result.Error = res.Error.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case sum := <-stream.Summary:
+		report := htmlReport{
+			Title:       "Lab test report",
+			GeneratedAt: generatedAt.UTC().Format(time.RFC3339),
+			Duration:    sum.Duration.Round(time.Millisecond).String(),
+			Total:       sum.Passed + sum.Failed + sum.Skipped + sum.Cancelled,
+			Passed:      sum.Passed,
+			Failed:      sum.Failed,
+			Skipped:     sum.Skipped,
+			Cancelled:   sum.Cancelled,
+			Histogram:   htmlHistogram(results),
+			Profile:     sum.Profile,
+			Shard:       shardDescription(sum.Shard),
+			Results:     results,
+		}
+
+		if err := htmlTemplate.Execute(h.out, report); err != nil {
+			return fmt.Errorf("write html report: %w", err)
+		}
+
+		if sum.HasErrors() {
+			return ErrHasErrors
+		}
+
+		return nil
+	}
+}
+
+// htmlHistogram groups result durations into equally sized buckets between
+// the fastest and the slowest result. Skipped and cancelled results did not
+// run and are left out.
+func htmlHistogram(all []htmlResult) []htmlBucket {
+	results := make([]htmlResult, 0, len(all))
+
+	for _, res := range all {
+		if res.Status != "skipped" && res.Status != "cancelled" {
+			results = append(results, res)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	low := results[0].DurationNs
+	high := results[0].DurationNs
+
+	for _, res := range results[1:] {
+		low = min(low, res.DurationNs)
+		high = max(high, res.DurationNs)
+	}
+
+	count := htmlHistogramBuckets
+
+	if high == low {
+		count = 1
+	}
+
+	width := (high - low) / int64(count)
+
+	if width == 0 {
+		width = 1
+	}
+
+	buckets := make([]htmlBucket, count)
+
+	for _, res := range results {
+		index := int((res.DurationNs - low) / width)
+
+		if index >= count {
+			index = count - 1
+		}
+
+		buckets[index].Count++
+	}
+
+	highest := 0
+
+	for _, bucket := range buckets {
+		highest = max(highest, bucket.Count)
+	}
+
+	for i := range buckets {
+		from := time.Duration(low + int64(i)*width)
+		to := time.Duration(low + int64(i+1)*width)
+
+		if i == count-1 {
+			to = time.Duration(high)
+		}
+
+		buckets[i].Label = fmt.Sprintf("%s–%s", from.Round(time.Millisecond), to.Round(time.Millisecond))
+		buckets[i].Percent = buckets[i].Count * 100 / highest
+	}
+
+	return buckets
+}
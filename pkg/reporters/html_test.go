@@ -0,0 +1,90 @@
+package reporters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestHTMLReporterWritesSelfContainedPage(t *testing.T) {
+	progress := make(chan runner.Result, 3)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "fast.fql", Duration: 10 * time.Millisecond}
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "legacy.fail.fql", Duration: 20 * time.Millisecond, Warning: "deprecated"}
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 2,
+		Filename: "slow.yaml",
+		Duration: 110 * time.Millisecond,
+		Error:    errors.New("expected <script>alert(1)</script>"),
+	}
+	close(progress)
+	summary <- runner.Summary{Passed: 2, Failed: 1, Duration: time.Second}
+	close(summary)
+
+	var out bytes.Buffer
+	err := reporters.NewHTML(&out).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) {
+		t.Fatalf("expected has errors, got %v", err)
+	}
+
+	page := out.String()
+
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		"<strong>3</strong>Total",
+		"<strong>2</strong>Passed",
+		"<strong>1</strong>Failed",
+		"<td>slow.yaml</td>",
+		`<td class="failed">failed</td>`,
+		"expected &lt;script&gt;alert(1)&lt;/script&gt;",
+		`<pre class="warning">deprecated</pre>`,
+		`title="10ms–20ms: 1"`,
+		`title="20ms–30ms: 1"`,
+		`title="100ms–110ms: 1"`,
+		`header.addEventListener("click"`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Fatalf("expected page to contain %q, got:\n%s", expected, page)
+		}
+	}
+
+	for _, external := range []string{"<link", "src=", "@import"} {
+		if strings.Contains(page, external) {
+			t.Fatalf("expected no external assets, found %q", external)
+		}
+	}
+}
+
+func TestHTMLReporterLeavesSkippedResultsOutOfHistogram(t *testing.T) {
+	progress := make(chan runner.Result, 4)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "fast.fql", Duration: 10 * time.Millisecond}
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "slow.fql", Duration: 110 * time.Millisecond}
+	progress <- runner.Result{Filename: "skipped.yaml", Skipped: true, SkipReason: "not tagged"}
+	progress <- runner.Result{Filename: "cancelled.yaml", Skipped: true, Cancelled: true, SkipReason: "run stopped"}
+	close(progress)
+	summary <- runner.Summary{Passed: 2, Skipped: 1, Cancelled: 1, Duration: time.Second}
+	close(summary)
+
+	var out bytes.Buffer
+	if err := reporters.NewHTML(&out).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	page := out.String()
+
+	if !strings.Contains(page, `title="10ms–20ms: 1"`) || !strings.Contains(page, `title="100ms–110ms: 1"`) {
+		t.Fatalf("expected histogram of the executed results only, got:\n%s", page)
+	}
+
+	if strings.Contains(page, `title="0s–`) {
+		t.Fatalf("expected skipped results to be left out of the histogram, got:\n%s", page)
+	}
+}
@@ -0,0 +1,188 @@
+package reporters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+// JSONSchemaVersion identifies the event schema written by the JSON reporter.
+//
+// The reporter writes newline-delimited JSON (NDJSON): one object per line,
+// in the order the runner delivers results, followed by exactly one summary.
+// Every event carries "schema" and "type" fields.
+//
+// A "result" event is written as soon as a test file finishes:
+//
+//	schema      string  always JSONSchemaVersion
+//	type        string  "result"
+//	file        string  file name or URL the result belongs to
+//	status      string  "passed", "failed", "skipped" or "cancelled"
+//	attempts    number  executions performed, including retries
+//	times       number  successful executions
+//	durationMs  number  average execution duration in milliseconds
+//	error       string  failure message, omitted when the test passed
+//	warning     string  deprecation or unchecked runtime version warning, omitted when empty
+//	reason      string  why the test was skipped or cancelled, omitted otherwise
+//
+// The "summary" event is written once, after the last result:
+//
+//	schema      string  always JSONSchemaVersion
+//	type        string  "summary"
+//	passed      number  passed results
+//	failed      number  failed results
+//	skipped     number  skipped results
+//	cancelled   number  results cancelled after reaching the failure limit
+//	shard       object  omitted unless a single shard was run:
+//	  index       number  1-based shard index
+//	  count       number  total number of shards
+//	  assigned    number  files assigned to this shard
+//	  discovered  number  files discovered across all shards
+//	profile     string  config profile the run used, omitted when empty
+//	durationMs  number  wall-clock duration of the run in milliseconds
+//
+// Fields are only added within a schema version; renaming or removing a
+// field, or changing its meaning, requires a new version.
+const JSONSchemaVersion = "lab.report/v1"
+
+const (
+	jsonEventResult  = "result"
+	jsonEventSummary = "summary"
+
+	jsonStatusPassed    = "passed"
+	jsonStatusFailed    = "failed"
+	jsonStatusSkipped   = "skipped"
+	jsonStatusCancelled = "cancelled"
+)
+
+type (
+	JSON struct {
+		encoder *json.Encoder
+	}
+
+	jsonResultEvent struct {
+		Schema     string  `json:"schema"`
+		Type       string  `json:"type"`
+		File       string  `json:"file"`
+		Status     string  `json:"status"`
+		Attempts   uint64  `json:"attempts"`
+		Times      uint64  `json:"times"`
+		DurationMs float64 `json:"durationMs"`
+		Error      string  `json:"error,omitempty"`
+		Warning    string  `json:"warning,omitempty"`
+		Reason     string  `json:"reason,omitempty"`
+	}
+
+	jsonSummaryEvent struct {
+		Schema     string     `json:"schema"`
+		Type       string     `json:"type"`
+		Passed     int        `json:"passed"`
+		Failed     int        `json:"failed"`
+		Skipped    int        `json:"skipped"`
+		Cancelled  int        `json:"cancelled"`
+		DurationMs float64    `json:"durationMs"`
+		Profile    string     `json:"profile,omitempty"`
+		Shard      *jsonShard `json:"shard,omitempty"`
+	}
+
+	jsonShard struct {
+		Index      uint64 `json:"index"`
+		Count      uint64 `json:"count"`
+		Assigned   int    `json:"assigned"`
+		Discovered int    `json:"discovered"`
+	}
+)
+
+func NewJSON(out io.Writer) *JSON {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+
+	return &JSON{encoder: encoder}
+}
+
+func (j *JSON) Report(ctx context.Context, stream runner.Stream) error {
+	var writeErr error
+
+	for res := range stream.Progress {
+		// keep draining the stream after a write failure so the runner can finish
+		if writeErr != nil {
+			continue
+		}
+
+		evt := jsonResultEvent{
+			Schema:     JSONSchemaVersion,
+			Type:       jsonEventResult,
+			File:       res.Filename,
+			Status:     jsonStatusPassed,
+			Attempts:   res.Attempts,
+			Times:      res.Times,
+			DurationMs: milliseconds(res.Duration),
+			Warning:    res.Warning,
+		}
+
+		switch {
+		case res.Cancelled:
+			evt.Status = jsonStatusCancelled
+			evt.Reason = res.SkipReason
+		case res.Skipped:
+			evt.Status = jsonStatusSkipped
+			evt.Reason = res.SkipReason
+		case res.Error != nil:
+			evt.Status = jsonStatusFailed
+			evt.Error = res.Error.Error()
+		}
+
+		writeErr = j.write(evt)
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case sum := <-stream.Summary:
+		// the summary is received even after a write failure so the runner does not block on it
+		if writeErr != nil {
+			return writeErr
+		}
+
+		evt := jsonSummaryEvent{
+			Schema:     JSONSchemaVersion,
+			Type:       jsonEventSummary,
+			Passed:     sum.Passed,
+			Failed:     sum.Failed,
+			Skipped:    sum.Skipped,
+			Cancelled:  sum.Cancelled,
+			DurationMs: milliseconds(sum.Duration),
+			Profile:    sum.Profile,
+		}
+
+		if sum.Shard != nil {
+			evt.Shard = &jsonShard{
+				Index:      sum.Shard.Index,
+				Count:      sum.Shard.Count,
+				Assigned:   sum.Shard.Assigned,
+				Discovered: sum.Shard.Discovered,
+			}
+		}
+
+		if err := j.write(evt); err != nil {
+			return err
+		}
+
+		if sum.HasErrors() {
+			return ErrHasErrors
+		}
+
+		return nil
+	}
+}
+
+func (j *JSON) write(evt any) error {
+	if err := j.encoder.Encode(evt); err != nil {
+		return fmt.Errorf("write json report: %w", err)
+	}
+
+	return nil
+}
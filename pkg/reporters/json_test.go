@@ -0,0 +1,112 @@
+package reporters_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestJSONReporterWritesEvents(t *testing.T) {
+	progress := make(chan runner.Result, 2)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 1,
+		Filename: "passed.fql",
+		Duration: 1500 * time.Microsecond,
+		Warning:  "deprecated",
+	}
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 2,
+		Filename: "failed.yaml",
+		Duration: 20 * time.Millisecond,
+		Error:    errors.New("expected <true>"),
+	}
+	close(progress)
+	summary <- runner.Summary{Passed: 1, Failed: 1, Duration: time.Second, Profile: "staging"}
+	close(summary)
+
+	var out bytes.Buffer
+	err := reporters.NewJSON(&out).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) {
+		t.Fatalf("expected has errors, got %v", err)
+	}
+
+	events := make([]map[string]any, 0, 3)
+	scanner := bufio.NewScanner(&out)
+
+	for scanner.Scan() {
+		evt := make(map[string]any)
+		if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+			t.Fatalf("expected one JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+
+		events = append(events, evt)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+
+	for _, evt := range events {
+		if evt["schema"] != reporters.JSONSchemaVersion {
+			t.Fatalf("expected schema %q, got %v", reporters.JSONSchemaVersion, evt["schema"])
+		}
+	}
+
+	passed := events[0]
+	if passed["type"] != "result" || passed["file"] != "passed.fql" || passed["status"] != "passed" ||
+		passed["durationMs"] != 1.5 || passed["warning"] != "deprecated" {
+		t.Fatalf("unexpected passed event: %v", passed)
+	}
+
+	if _, exists := passed["error"]; exists {
+		t.Fatalf("expected passed event without error, got %v", passed)
+	}
+
+	failed := events[1]
+	if failed["status"] != "failed" || failed["error"] != "expected <true>" || failed["attempts"] != float64(2) {
+		t.Fatalf("unexpected failed event: %v", failed)
+	}
+
+	sum := events[2]
+	if sum["type"] != "summary" || sum["passed"] != float64(1) || sum["failed"] != float64(1) || sum["durationMs"] != float64(1000) ||
+		sum["profile"] != "staging" {
+		t.Fatalf("unexpected summary event: %v", sum)
+	}
+}
+
+func TestJSONReporterReceivesSummaryAfterWriteFailure(t *testing.T) {
+	progress := make(chan runner.Result, 1)
+	summary := make(chan runner.Summary)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "test.fql"}
+	close(progress)
+
+	sent := make(chan struct{})
+
+	go func() {
+		summary <- runner.Summary{Passed: 1}
+		close(sent)
+	}()
+
+	writeErr := errors.New("disk full")
+
+	err := reporters.NewJSON(failingWriter{err: writeErr}).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("expected the summary to be received")
+	}
+}
@@ -0,0 +1,171 @@
+package reporters
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+const junitSuiteName = "lab"
+
+type (
+	// JUnit collects runner results and writes them as a single JUnit XML
+	// document once the summary arrives.
+	JUnit struct {
+		out io.Writer
+	}
+
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Errors   int              `xml:"errors,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name       string          `xml:"name,attr"`
+		Tests      int             `xml:"tests,attr"`
+		Failures   int             `xml:"failures,attr"`
+		Errors     int             `xml:"errors,attr"`
+		Skipped    int             `xml:"skipped,attr"`
+		Time       string          `xml:"time,attr"`
+		Timestamp  string          `xml:"timestamp,attr"`
+		Properties []junitProperty `xml:"properties>property,omitempty"`
+		Cases      []junitTestCase `xml:"testcase"`
+	}
+
+	junitTestCase struct {
+		Name       string          `xml:"name,attr"`
+		ClassName  string          `xml:"classname,attr"`
+		Time       string          `xml:"time,attr"`
+		Properties []junitProperty `xml:"properties>property,omitempty"`
+		Failure    *junitFailure   `xml:"failure,omitempty"`
+		Skipped    *junitSkipped   `xml:"skipped,omitempty"`
+		SystemOut  string          `xml:"system-out,omitempty"`
+	}
+
+	junitProperty struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+
+	junitSkipped struct {
+		Message string `xml:"message,attr,omitempty"`
+	}
+)
+
+func NewJUnit(out io.Writer) *JUnit {
+	return &JUnit{out: out}
+}
+
+func (j *JUnit) Report(ctx context.Context, stream runner.Stream) error {
+	startTime := time.Now()
+	cases := make([]junitTestCase, 0)
+	failures := 0
+	skipped := 0
+
+	for res := range stream.Progress {
+		testCase := junitTestCase{
+			Name:      res.Filename,
+			ClassName: junitSuiteName,
+			Time:      junitSeconds(res.Duration),
+			Properties: []junitProperty{
+				{Name: "attempts", Value: strconv.FormatUint(res.Attempts, 10)},
+				{Name: "times", Value: strconv.FormatUint(res.Times, 10)},
+			},
+			SystemOut: res.Warning,
+		}
+
+		switch {
+		case res.Skipped:
+			skipped++
+			testCase.Skipped = &junitSkipped{Message: res.SkipReason}
+		case res.Error != nil:
+			failures++
+			testCase.Failure = &junitFailure{
+				Message: res.Error.Error(),
+				Type:    "error",
+				Text:    res.Error.Error(),
+			}
+		}
+
+		cases = append(cases, testCase)
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case sum := <-stream.Summary:
+		duration := junitSeconds(sum.Duration)
+		doc := junitTestSuites{
+			Name:     junitSuiteName,
+			Tests:    len(cases),
+			Failures: failures,
+			Skipped:  skipped,
+			Time:     duration,
+			Suites: []junitTestSuite{
+				{
+					Name:      junitSuiteName,
+					Tests:     len(cases),
+					Failures:  failures,
+					Skipped:   skipped,
+					Time:      duration,
+					Timestamp: startTime.UTC().Format(time.RFC3339),
+					Cases:     cases,
+				},
+			},
+		}
+
+		if sum.Profile != "" {
+			doc.Suites[0].Properties = []junitProperty{{Name: "profile", Value: sum.Profile}}
+		}
+
+		if err := j.write(doc); err != nil {
+			return err
+		}
+
+		if sum.HasErrors() {
+			return ErrHasErrors
+		}
+
+		return nil
+	}
+}
+
+func (j *JUnit) write(doc junitTestSuites) error {
+	if _, err := io.WriteString(j.out, xml.Header); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	encoder := xml.NewEncoder(j.out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	if _, err := io.WriteString(j.out, "\n"); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	return nil
+}
+
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,100 @@
+package reporters_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestJUnitReporterWritesTestCases(t *testing.T) {
+	progress := make(chan runner.Result, 2)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{
+		Times:    2,
+		Attempts: 2,
+		Filename: "passed.fql",
+		Duration: 1500 * time.Millisecond,
+		Warning:  "deprecated",
+	}
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 3,
+		Filename: "failed.yaml",
+		Duration: 250 * time.Millisecond,
+		Error:    errors.New("expected <true>"),
+	}
+	close(progress)
+	summary <- runner.Summary{Passed: 1, Failed: 1, Duration: 2 * time.Second, Profile: "staging"}
+	close(summary)
+
+	var out bytes.Buffer
+	err := reporters.NewJUnit(&out).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) {
+		t.Fatalf("expected has errors, got %v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), xml.Header) {
+		t.Fatalf("expected XML header, got %q", out.String())
+	}
+
+	var doc struct {
+		Tests    int    `xml:"tests,attr"`
+		Failures int    `xml:"failures,attr"`
+		Time     string `xml:"time,attr"`
+		Suite    struct {
+			Properties []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"properties>property"`
+			Cases []struct {
+				Name       string `xml:"name,attr"`
+				Time       string `xml:"time,attr"`
+				Properties []struct {
+					Name  string `xml:"name,attr"`
+					Value string `xml:"value,attr"`
+				} `xml:"properties>property"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+				SystemOut string `xml:"system-out"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("expected valid XML, got %v\n%s", err, out.String())
+	}
+
+	if doc.Tests != 2 || doc.Failures != 1 || doc.Time != "2.000" {
+		t.Fatalf("unexpected totals: %+v", doc)
+	}
+
+	if len(doc.Suite.Properties) != 1 || doc.Suite.Properties[0].Name != "profile" || doc.Suite.Properties[0].Value != "staging" {
+		t.Fatalf("expected the profile as a suite property, got %+v", doc.Suite.Properties)
+	}
+
+	if len(doc.Suite.Cases) != 2 {
+		t.Fatalf("expected two test cases, got %d", len(doc.Suite.Cases))
+	}
+
+	passed := doc.Suite.Cases[0]
+	if passed.Name != "passed.fql" || passed.Time != "1.500" || passed.Failure != nil || passed.SystemOut != "deprecated" {
+		t.Fatalf("unexpected passed case: %+v", passed)
+	}
+
+	if len(passed.Properties) != 2 || passed.Properties[0].Value != "2" || passed.Properties[1].Value != "2" {
+		t.Fatalf("unexpected passed case properties: %+v", passed.Properties)
+	}
+
+	failed := doc.Suite.Cases[1]
+	if failed.Name != "failed.yaml" || failed.Failure == nil || failed.Failure.Message != "expected <true>" {
+		t.Fatalf("unexpected failed case: %+v", failed)
+	}
+}
@@ -0,0 +1,110 @@
+package reporters
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+// Multi tees a single runner stream into several reporters and joins their errors.
+type Multi struct {
+	reporters []Reporter
+}
+
+func NewMulti(reporters ...Reporter) *Multi {
+	return &Multi{reporters: reporters}
+}
+
+func (m *Multi) Report(ctx context.Context, stream runner.Stream) error {
+	count := len(m.reporters)
+	progress := make([]chan runner.Result, count)
+	summaries := make([]chan runner.Summary, count)
+	done := make([]chan struct{}, count)
+	errs := make([]error, count)
+
+	var wg sync.WaitGroup
+
+	for i, reporter := range m.reporters {
+		progress[i] = make(chan runner.Result)
+		summaries[i] = make(chan runner.Summary, 1)
+		done[i] = make(chan struct{})
+
+		wg.Add(1)
+
+		go func(i int, reporter Reporter) {
+			defer wg.Done()
+			defer close(done[i])
+
+			errs[i] = reporter.Report(ctx, runner.Stream{
+				Progress: progress[i],
+				Summary:  summaries[i],
+			})
+		}(i, reporter)
+	}
+
+	for res := range stream.Progress {
+		for i := range progress {
+			// a reporter that already returned must not block the others
+			select {
+			case progress[i] <- res:
+			case <-done[i]:
+			}
+		}
+	}
+
+	for i := range progress {
+		close(progress[i])
+	}
+
+	// summary channels are left open on cancellation so that reporters
+	// observe the canceled context instead of a zero summary
+	select {
+	case <-ctx.Done():
+	case sum, open := <-stream.Summary:
+		for i := range summaries {
+			if open {
+				summaries[i] <- sum
+			}
+
+			close(summaries[i])
+		}
+	}
+
+	wg.Wait()
+
+	return joinErrors(errs)
+}
+
+func joinErrors(errs []error) error {
+	unique := make([]error, 0, len(errs))
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		duplicate := false
+
+		for _, existing := range unique {
+			if existing == err {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			unique = append(unique, err)
+		}
+	}
+
+	switch len(unique) {
+	case 0:
+		return nil
+	case 1:
+		return unique[0]
+	default:
+		return errors.Join(unique...)
+	}
+}
@@ -0,0 +1,87 @@
+package reporters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestMultiReporterTeesStream(t *testing.T) {
+	progress := make(chan runner.Result, 1)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "test.fql"}
+	close(progress)
+	summary <- runner.Summary{Passed: 1}
+	close(summary)
+
+	var simpleOut bytes.Buffer
+	var junitOut bytes.Buffer
+
+	reporter := reporters.NewMulti(reporters.NewSimple(&simpleOut), reporters.NewJUnit(&junitOut))
+	if err := reporter.Report(context.Background(), runner.Stream{Progress: progress, Summary: summary}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(simpleOut.String(), `PASS file="test.fql"`) || !strings.Contains(simpleOut.String(), "DONE passed=1") {
+		t.Fatalf("unexpected simple output: %q", simpleOut.String())
+	}
+
+	if !strings.Contains(junitOut.String(), `<testcase name="test.fql"`) {
+		t.Fatalf("unexpected junit output: %q", junitOut.String())
+	}
+}
+
+func TestMultiReporterJoinsErrors(t *testing.T) {
+	progress := make(chan runner.Result, 1)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "test.fql", Error: errors.New("failed")}
+	close(progress)
+	summary <- runner.Summary{Failed: 1}
+	close(summary)
+
+	writeErr := errors.New("disk full")
+	reporter := reporters.NewMulti(
+		reporters.NewSimple(io.Discard),
+		reporters.NewJUnit(io.Discard),
+		reporters.NewJUnit(failingWriter{err: writeErr}),
+	)
+
+	err := reporter.Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) || !errors.Is(err, writeErr) {
+		t.Fatalf("expected joined reporter errors, got %v", err)
+	}
+
+	if count := strings.Count(err.Error(), reporters.ErrHasErrors.Error()); count != 1 {
+		t.Fatalf("expected failed summary error once, got %q", err.Error())
+	}
+}
+
+func TestMultiReporterStopsOnCancellation(t *testing.T) {
+	progress := make(chan runner.Result)
+	summary := make(chan runner.Summary)
+	close(progress)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reporter := reporters.NewMulti(reporters.NewSimple(io.Discard), reporters.NewJUnit(io.Discard))
+
+	err := reporter.Report(ctx, runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(_ []byte) (int, error) {
+	return 0, w.err
+}
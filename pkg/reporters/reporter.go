@@ -2,23 +2,65 @@ package reporters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"time"
 
 	"github.com/MontFerret/lab/v2/pkg/runner"
 )
 
-type Reporter interface {
-	Report(ctx context.Context, stream runner.Stream) error
+// ErrHasErrors is returned by reporters when the summary contains failed tests.
+var ErrHasErrors = errors.New("has errors")
+
+type (
+	Reporter interface {
+		Report(ctx context.Context, stream runner.Stream) error
+	}
+
+	Factory func(out io.Writer) Reporter
+)
+
+var factoryByName = map[string]Factory{
+	"":        func(out io.Writer) Reporter { return NewConsole(out) },
+	"console": func(out io.Writer) Reporter { return NewConsole(out) },
+	"simple":  func(out io.Writer) Reporter { return NewSimple(out) },
+	"junit":   func(out io.Writer) Reporter { return NewJUnit(out) },
+	"json":    func(out io.Writer) Reporter { return NewJSON(out) },
+	"tap":     func(out io.Writer) Reporter { return NewTAP(out) },
+	"html":    func(out io.Writer) Reporter { return NewHTML(out) },
+	"github":  func(out io.Writer) Reporter { return NewGitHub(out, os.Getenv(GitHubStepSummaryEnv)) },
 }
 
 func New(name string, out io.Writer) (Reporter, error) {
-	switch name {
-	case "", "console":
-		return NewConsole(out), nil
-	case "simple":
-		return NewSimple(out), nil
-	default:
+	factory, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return factory(out), nil
+}
+
+// Lookup returns the factory of a registered reporter without creating it.
+func Lookup(name string) (Factory, error) {
+	factory, found := factoryByName[name]
+	if !found {
 		return nil, fmt.Errorf("unknown reporter: %s", name)
 	}
+
+	return factory, nil
+}
+
+// shardDescription summarizes a sharded run, e.g. "Shard 2/3: 4 of 12 files".
+func shardDescription(shard *runner.ShardSummary) string {
+	if shard == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("Shard %d/%d: %d of %d files", shard.Index, shard.Count, shard.Assigned, shard.Discovered)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
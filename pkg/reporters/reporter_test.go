@@ -64,3 +64,66 @@ func TestReportersRenderDeprecationWarningOnce(t *testing.T) {
 		})
 	}
 }
+
+func TestReportersRenderSkippedResults(t *testing.T) {
+	const reason = `excluded by tag "slow"`
+
+	tests := []struct {
+		name      string
+		newReport func(*bytes.Buffer) reporters.Reporter
+		markers   []string
+	}{
+		{
+			name:      "console",
+			newReport: func(out *bytes.Buffer) reporters.Reporter { return reporters.NewConsole(out) },
+			markers:   []string{"Skipped", "Reason="},
+		},
+		{
+			name:      "simple",
+			newReport: func(out *bytes.Buffer) reporters.Reporter { return reporters.NewSimple(out) },
+			markers:   []string{`SKIP file="slow.yaml" reason="excluded by tag \"slow\""`, "DONE passed=0 failed=0 skipped=1"},
+		},
+		{
+			name:      "json",
+			newReport: func(out *bytes.Buffer) reporters.Reporter { return reporters.NewJSON(out) },
+			markers:   []string{`"status":"skipped"`, `"reason":"excluded by tag \"slow\""`, `"skipped":1`},
+		},
+		{
+			name:      "junit",
+			newReport: func(out *bytes.Buffer) reporters.Reporter { return reporters.NewJUnit(out) },
+			markers:   []string{`skipped="1"`, `<skipped message="excluded by tag &#34;slow&#34;"></skipped>`},
+		},
+		{
+			name:      "html",
+			newReport: func(out *bytes.Buffer) reporters.Reporter { return reporters.NewHTML(out) },
+			markers:   []string{`<strong>1</strong>Skipped`, `<td class="skipped">skipped</td>`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			progress := make(chan runner.Result, 1)
+			summary := make(chan runner.Summary, 1)
+			progress <- runner.Result{
+				Filename:   "slow.yaml",
+				Skipped:    true,
+				SkipReason: reason,
+			}
+			close(progress)
+			summary <- runner.Summary{Skipped: 1}
+			close(summary)
+
+			var out bytes.Buffer
+			reporter := test.newReport(&out)
+			if err := reporter.Report(context.Background(), runner.Stream{Progress: progress, Summary: summary}); err != nil {
+				t.Fatalf("expected skipped results not to fail the run, got %v", err)
+			}
+
+			for _, marker := range test.markers {
+				if !strings.Contains(out.String(), marker) {
+					t.Fatalf("expected output to contain %q, got %q", marker, out.String())
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package reporters
 
 import (
 	"context"
-	"errors"
 
 	"github.com/MontFerret/lab/v2/pkg/runner"
 )
@@ -19,7 +18,7 @@ func (c *Silent) Report(ctx context.Context, stream runner.Stream) error {
 		return context.Canceled
 	case sum := <-stream.Summary:
 		if sum.HasErrors() {
-			return errors.New("has errors")
+			return ErrHasErrors
 		}
 	}
 
@@ -2,7 +2,6 @@ package reporters
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 
@@ -23,6 +22,16 @@ func (s *Simple) Report(ctx context.Context, stream runner.Stream) error {
 			fmt.Fprintf(s.out, "WARN file=%q warning=%q\n", res.Filename, res.Warning)
 		}
 
+		if res.Cancelled {
+			fmt.Fprintf(s.out, "CANCEL file=%q reason=%q\n", res.Filename, res.SkipReason)
+			continue
+		}
+
+		if res.Skipped {
+			fmt.Fprintf(s.out, "SKIP file=%q reason=%q\n", res.Filename, res.SkipReason)
+			continue
+		}
+
 		if res.Error != nil {
 			fmt.Fprintf(s.out, "FAIL file=%q duration=%s attempts=%d times=%d error=%q\n", res.Filename, res.Duration, res.Attempts, res.Times, res.Error.Error())
 			continue
@@ -35,10 +44,28 @@ func (s *Simple) Report(ctx context.Context, stream runner.Stream) error {
 	case <-ctx.Done():
 		return context.Canceled
 	case sum := <-stream.Summary:
-		fmt.Fprintf(s.out, "DONE passed=%d failed=%d duration=%s\n", sum.Passed, sum.Failed, sum.Duration)
+		if sum.Profile != "" {
+			fmt.Fprintf(s.out, "PROFILE name=%q\n", sum.Profile)
+		}
+
+		if sum.Shard != nil {
+			fmt.Fprintf(s.out, "SHARD index=%d count=%d assigned=%d discovered=%d\n", sum.Shard.Index, sum.Shard.Count, sum.Shard.Assigned, sum.Shard.Discovered)
+		}
+
+		fmt.Fprintf(s.out, "DONE passed=%d failed=%d", sum.Passed, sum.Failed)
+
+		if sum.Skipped > 0 {
+			fmt.Fprintf(s.out, " skipped=%d", sum.Skipped)
+		}
+
+		if sum.Cancelled > 0 {
+			fmt.Fprintf(s.out, " cancelled=%d", sum.Cancelled)
+		}
+
+		fmt.Fprintf(s.out, " duration=%s\n", sum.Duration)
 
 		if sum.HasErrors() {
-			return errors.New("has errors")
+			return ErrHasErrors
 		}
 
 		return nil
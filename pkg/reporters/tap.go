@@ -0,0 +1,151 @@
+package reporters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+type (
+	// TAP writes results in the Test Anything Protocol version 13 format.
+	// The plan line is written last, once the summary is known.
+	TAP struct {
+		out io.Writer
+	}
+
+	tapDiagnostic struct {
+		Message    string  `yaml:"message"`
+		Severity   string  `yaml:"severity"`
+		Attempts   uint64  `yaml:"attempts"`
+		Times      uint64  `yaml:"times"`
+		DurationMs float64 `yaml:"duration_ms"`
+	}
+)
+
+func NewTAP(out io.Writer) *TAP {
+	return &TAP{out: out}
+}
+
+func (t *TAP) Report(ctx context.Context, stream runner.Stream) error {
+	w := bufio.NewWriter(t.out)
+	number := 0
+
+	fmt.Fprintln(w, "TAP version 13")
+
+	var writeErr error
+
+	for res := range stream.Progress {
+		// keep draining the stream after a write failure so the runner can finish
+		if writeErr != nil {
+			continue
+		}
+
+		number++
+		writeErr = t.writeResult(w, number, res)
+	}
+
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case sum := <-stream.Summary:
+		// the summary is received even after a write failure so the runner does not block on it
+		if writeErr != nil {
+			return writeErr
+		}
+
+		fmt.Fprintf(w, "1..%d\n", sum.Passed+sum.Failed+sum.Skipped+sum.Cancelled)
+		fmt.Fprintf(w, "# passed %d\n", sum.Passed)
+		fmt.Fprintf(w, "# failed %d\n", sum.Failed)
+
+		if sum.Skipped > 0 {
+			fmt.Fprintf(w, "# skipped %d\n", sum.Skipped)
+		}
+
+		if sum.Cancelled > 0 {
+			fmt.Fprintf(w, "# cancelled %d\n", sum.Cancelled)
+		}
+
+		if sum.Profile != "" {
+			fmt.Fprintf(w, "# profile %s\n", sum.Profile)
+		}
+
+		if sum.Shard != nil {
+			fmt.Fprintf(w, "# shard %d/%d assigned %d of %d files\n", sum.Shard.Index, sum.Shard.Count, sum.Shard.Assigned, sum.Shard.Discovered)
+		}
+
+		fmt.Fprintf(w, "# duration %s\n", sum.Duration)
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("write tap report: %w", err)
+		}
+
+		if sum.HasErrors() {
+			return ErrHasErrors
+		}
+
+		return nil
+	}
+}
+
+func (t *TAP) writeResult(w *bufio.Writer, number int, res runner.Result) error {
+	if res.Warning != "" {
+		fmt.Fprintf(w, "# WARN %s: %s\n", tapEscape(res.Filename), tapEscape(res.Warning))
+	}
+
+	switch {
+	case res.Skipped:
+		fmt.Fprintf(w, "ok %d - %s # SKIP %s\n", number, tapEscape(res.Filename), tapEscape(res.SkipReason))
+	case res.Error == nil:
+		fmt.Fprintf(w, "ok %d - %s\n", number, tapEscape(res.Filename))
+	default:
+		fmt.Fprintf(w, "not ok %d - %s\n", number, tapEscape(res.Filename))
+
+		if err := t.writeDiagnostic(w, tapDiagnostic{
+			Message:    res.Error.Error(),
+			Severity:   "fail",
+			Attempts:   res.Attempts,
+			Times:      res.Times,
+			DurationMs: milliseconds(res.Duration),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// flush per result so that harnesses can consume the output as it streams
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write tap report: %w", err)
+	}
+
+	return nil
+}
+
+func (t *TAP) writeDiagnostic(w io.Writer, diagnostic tapDiagnostic) error {
+	data, err := yaml.Marshal(diagnostic)
+	if err != nil {
+		return fmt.Errorf("write tap report: %w", err)
+	}
+
+	fmt.Fprintln(w, "  ---")
+
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+
+	fmt.Fprintln(w, "  ...")
+
+	return nil
+}
+
+// tapEscape keeps descriptions on a single line and prevents "#" from being
+// parsed as a directive.
+func tapEscape(value string) string {
+	value = strings.ReplaceAll(value, "#", "\\#")
+
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,95 @@
+package reporters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/reporters"
+	"github.com/MontFerret/lab/v2/pkg/runner"
+)
+
+func TestTAPReporterWritesResultsAndPlan(t *testing.T) {
+	progress := make(chan runner.Result, 3)
+	summary := make(chan runner.Summary, 1)
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 1,
+		Filename: "passed #1.fql",
+		Warning:  "deprecated",
+	}
+	progress <- runner.Result{
+		Times:    1,
+		Attempts: 3,
+		Filename: "failed.yaml",
+		Duration: 5 * time.Millisecond,
+		Error:    errors.New("expected: true\ngot: false"),
+	}
+	progress <- runner.Result{Filename: "slow.yaml", Skipped: true, SkipReason: `excluded by tag "slow"`}
+	close(progress)
+	summary <- runner.Summary{Passed: 1, Failed: 1, Skipped: 1, Duration: time.Second}
+	close(summary)
+
+	var out bytes.Buffer
+	err := reporters.NewTAP(&out).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, reporters.ErrHasErrors) {
+		t.Fatalf("expected has errors, got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"TAP version 13",
+		`# WARN passed \#1.fql: deprecated`,
+		`ok 1 - passed \#1.fql`,
+		"not ok 2 - failed.yaml",
+		"  ---",
+		"  message: |-",
+		"    expected: true",
+		"    got: false",
+		"  severity: fail",
+		"  attempts: 3",
+		"  times: 1",
+		"  duration_ms: 5",
+		"  ...",
+		`ok 3 - slow.yaml # SKIP excluded by tag "slow"`,
+		"1..3",
+		"# passed 1",
+		"# failed 1",
+		"# skipped 1",
+		"# duration 1s",
+		"",
+	}, "\n")
+
+	if out.String() != expected {
+		t.Fatalf("unexpected TAP output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestTAPReporterReceivesSummaryAfterWriteFailure(t *testing.T) {
+	progress := make(chan runner.Result, 1)
+	summary := make(chan runner.Summary)
+	progress <- runner.Result{Times: 1, Attempts: 1, Filename: "test.fql"}
+	close(progress)
+
+	sent := make(chan struct{})
+
+	go func() {
+		summary <- runner.Summary{Passed: 1}
+		close(sent)
+	}()
+
+	writeErr := errors.New("disk full")
+
+	err := reporters.NewTAP(failingWriter{err: writeErr}).Report(context.Background(), runner.Stream{Progress: progress, Summary: summary})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("expected the summary to be received")
+	}
+}
@@ -0,0 +1,264 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// dependencyGraph tracks the outcome of the files of one run so that
+	// files declaring dependencies start only once their dependencies passed.
+	dependencyGraph struct {
+		mu    sync.Mutex
+		nodes map[string]*dependencyNode
+		ended chan struct{}
+	}
+
+	dependencyNode struct {
+		deps     []string
+		arrived  bool
+		finished bool
+		// reason is empty when the file passed and tells how it did not
+		// otherwise, e.g. "failed".
+		reason string
+		done   chan struct{}
+	}
+
+	// fileOutcome aggregates the results of the cases of a file.
+	fileOutcome struct {
+		passed    bool
+		failed    bool
+		cancelled bool
+	}
+
+	dependentCase interface {
+		DependsOn() []string
+	}
+)
+
+const (
+	dependencyFailed    = "failed"
+	dependencySkipped   = "was skipped"
+	dependencyCancelled = "was cancelled"
+)
+
+func newDependencyGraph() *dependencyGraph {
+	return &dependencyGraph{
+		nodes: make(map[string]*dependencyNode),
+		ended: make(chan struct{}),
+	}
+}
+
+// node returns the node of the file, creating it for files that are
+// referenced as dependencies before they are read.
+func (g *dependencyGraph) node(name string) *dependencyNode {
+	node, found := g.nodes[name]
+
+	if !found {
+		node = &dependencyNode{done: make(chan struct{})}
+		g.nodes[name] = node
+	}
+
+	return node
+}
+
+// add records a file read from the source together with its dependencies.
+func (g *dependencyGraph) add(name string, deps []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node := g.node(name)
+	node.arrived = true
+	node.deps = append(node.deps, deps...)
+}
+
+// finish records how the file ended and releases the files waiting for it.
+// Only the first outcome of a file is kept.
+func (g *dependencyGraph) finish(name string, reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.finishLocked(name, reason)
+}
+
+func (g *dependencyGraph) finishLocked(name string, reason string) {
+	node := g.node(name)
+
+	if node.finished {
+		return
+	}
+
+	node.finished = true
+	node.reason = reason
+	close(node.done)
+}
+
+func (g *dependencyGraph) finished(name string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.node(name).finished
+}
+
+// wait blocks until every dependency finished and returns the reason the
+// dependent file has to be skipped for, if any. Dependencies that are not
+// read by the end of the source stream are never going to run.
+func (g *dependencyGraph) wait(ctx context.Context, deps []string) (string, error) {
+	for _, dep := range deps {
+		g.mu.Lock()
+		node := g.node(dep)
+		g.mu.Unlock()
+
+		select {
+		case <-node.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-g.ended:
+			g.mu.Lock()
+			arrived := node.arrived
+			g.mu.Unlock()
+
+			if !arrived {
+				return fmt.Sprintf("dependency %s is not part of the run", dep), nil
+			}
+
+			select {
+			case <-node.done:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		g.mu.Lock()
+		reason := node.reason
+		g.mu.Unlock()
+
+		if reason != "" {
+			return fmt.Sprintf("dependency %s %s", dep, reason), nil
+		}
+	}
+
+	return "", nil
+}
+
+// end marks the end of the source stream and fails the files that wait for
+// each other and therefore can never start.
+func (g *dependencyGraph) end() []Result {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	close(g.ended)
+
+	results := make([]Result, 0)
+
+	for _, cycle := range g.cycles() {
+		err := errors.New("dependency cycle: " + strings.Join(cycle, " -> "))
+
+		for _, name := range cycle[:len(cycle)-1] {
+			if g.nodes[name].finished {
+				continue
+			}
+
+			g.finishLocked(name, dependencyFailed)
+
+			results = append(results, Result{
+				Filename: name,
+				Error:    err,
+			})
+		}
+	}
+
+	return results
+}
+
+func (g *dependencyGraph) cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	names := make([]string, 0, len(g.nodes))
+
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	state := make(map[string]int, len(names))
+	stack := make([]string, 0)
+	cycles := make([][]string, 0)
+
+	var visit func(name string)
+
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dep := range g.nodes[name].deps {
+			node, found := g.nodes[dep]
+
+			if !found || !node.arrived || node.finished {
+				continue
+			}
+
+			switch state[dep] {
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						cycle := append(append([]string{}, stack[i:]...), dep)
+						cycles = append(cycles, cycle)
+
+						break
+					}
+				}
+			case unvisited:
+				visit(dep)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+	}
+
+	for _, name := range names {
+		node := g.nodes[name]
+
+		if node.arrived && !node.finished && state[name] == unvisited {
+			visit(name)
+		}
+	}
+
+	return cycles
+}
+
+func (o *fileOutcome) add(res Result) {
+	switch {
+	case res.Cancelled:
+		o.cancelled = true
+	case res.Skipped:
+	case res.Error != nil:
+		o.failed = true
+	default:
+		o.passed = true
+	}
+}
+
+// reason returns how the file ended for the files depending on it.
+func (o fileOutcome) reason() string {
+	switch {
+	case o.failed:
+		return dependencyFailed
+	case o.passed:
+		return ""
+	case o.cancelled:
+		return dependencyCancelled
+	default:
+		return dependencySkipped
+	}
+}
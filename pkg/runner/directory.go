@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	sources2 "github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+type (
+	// directories runs the hooks of the directory configs during one run.
+	// The beforeAll script of a directory runs when the first test under it
+	// starts, its afterAll script once every test of the run finished.
+	directories struct {
+		runner *Runner
+		// ctx is the context of the run; a beforeAll script serves every test
+		// under its directory and does not depend on the test starting it.
+		ctx     context.Context
+		params  testing2.Params
+		mu      sync.Mutex
+		entries map[string]*directory
+		started []*directory
+	}
+
+	directory struct {
+		once   sync.Once
+		config *testing2.Directory
+		params testing2.Params
+		err    error
+	}
+)
+
+func (r *Runner) newDirectories(ctx context.Context, params testing2.Params) *directories {
+	return &directories{
+		runner:  r,
+		ctx:     ctx,
+		params:  params,
+		entries: make(map[string]*directory),
+	}
+}
+
+// prepare returns the params of a test under the given directory configs:
+// the params of the test completed with the params of every directory and
+// the data of the closest beforeAll script. Params of the test take
+// precedence over the params of the directories. A test waits for the
+// beforeAll scripts of its directories but does not cancel them.
+func (d *directories) prepare(ctx context.Context, configs []sources2.File, params testing2.Params) (testing2.Params, error) {
+	if len(configs) == 0 {
+		return params, nil
+	}
+
+	parent := d.params
+
+	for _, file := range configs {
+		entry := d.entry(file.Name)
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			entry.once.Do(func() {
+				entry.params, entry.err = d.start(d.ctx, entry, file, parent)
+			})
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return params, ctx.Err()
+		}
+
+		if entry.err != nil {
+			return params, entry.err
+		}
+
+		parent = entry.params
+	}
+
+	return params.Inherit(parent), nil
+}
+
+func (d *directories) entry(name string) *directory {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, found := d.entries[name]
+
+	if !found {
+		entry = &directory{}
+		d.entries[name] = entry
+	}
+
+	return entry
+}
+
+func (d *directories) start(ctx context.Context, entry *directory, file sources2.File, parent testing2.Params) (testing2.Params, error) {
+	config, err := testing2.NewDirectory(testing2.Options{
+		File:    file,
+		Timeout: d.runner.testTimeout,
+	})
+
+	if err != nil {
+		return parent, fmt.Errorf("%s: %w", file.Name, err)
+	}
+
+	params := parent.Clone()
+	defined := d.params.ToMap()
+
+	for name, value := range config.Params() {
+		if _, found := defined[name]; !found {
+			params.SetUserValue(name, value)
+		}
+	}
+
+	if err := config.BeforeAll(ctx, d.runner.runtime, params); err != nil {
+		return params, fmt.Errorf("beforeAll %s: %w", file.Name, err)
+	}
+
+	// only directories whose beforeAll succeeded are torn down
+	entry.config = config
+
+	d.mu.Lock()
+	d.started = append(d.started, entry)
+	d.mu.Unlock()
+
+	return params, nil
+}
+
+// finish runs the afterAll scripts of the directories whose beforeAll
+// script succeeded, innermost first, and reports the failed ones.
+func (d *directories) finish(ctx context.Context, emit func(Result)) {
+	d.mu.Lock()
+	started := d.started
+	d.mu.Unlock()
+
+	// afterAll scripts run even when the run was stopped
+	ctx = context.WithoutCancel(ctx)
+
+	for i := len(started) - 1; i >= 0; i-- {
+		entry := started[i]
+
+		if err := entry.config.AfterAll(ctx, d.runner.runtime, entry.params); err != nil {
+			emit(Result{
+				Filename: entry.config.Name(),
+				Error:    fmt.Errorf("afterAll: %w", err),
+			})
+		}
+	}
+}
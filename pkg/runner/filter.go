@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/MontFerret/lab/v2/pkg/runtime"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+type (
+	// TagFilter selects test cases by the tags they declare. A case is selected
+	// when it has at least one included tag (or no include list is set) and none
+	// of the excluded tags.
+	TagFilter struct {
+		include []string
+		exclude []string
+	}
+
+	// runtimeVersion fetches the runtime version once, on first use. A lookup
+	// interrupted by its context is not remembered, so the next case retries.
+	runtimeVersion struct {
+		mu    sync.Mutex
+		done  bool
+		value string
+		err   error
+	}
+
+	taggedCase interface {
+		Tags() []string
+	}
+
+	skippableCase interface {
+		SkipReason() string
+	}
+
+	runtimeConstrainedCase interface {
+		RuntimeVersionConstraint() (testing2.VersionConstraint, bool)
+	}
+)
+
+// skipReason returns why a case must not run: an explicit manifest skip, the
+// tag filter, or an unmet runtime version constraint, in that order. A
+// constraint that cannot be checked because the runtime version is unknown
+// does not skip the case but returns a warning instead.
+func (r *Runner) skipReason(ctx context.Context, testCase testing2.Case) (reason string, warning string) {
+	if skippable, ok := testCase.(skippableCase); ok {
+		if reason := skippable.SkipReason(); reason != "" {
+			return reason, ""
+		}
+	}
+
+	var tags []string
+
+	if tagged, ok := testCase.(taggedCase); ok {
+		tags = tagged.Tags()
+	}
+
+	if reason := r.tags.SkipReason(tags); reason != "" {
+		return reason, ""
+	}
+
+	constrained, ok := testCase.(runtimeConstrainedCase)
+	if !ok {
+		return "", ""
+	}
+
+	constraint, ok := constrained.RuntimeVersionConstraint()
+	if !ok {
+		return "", ""
+	}
+
+	version, err := r.version.get(ctx, r.runtime)
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return fmt.Sprintf("cannot check runtime version %q: %s", constraint, err), ""
+	case err != nil:
+		return "", fmt.Sprintf("runtime version %q not checked: %s", constraint, err)
+	}
+
+	satisfied, err := constraint.Check(version)
+
+	switch {
+	case err != nil:
+		return "", fmt.Sprintf("runtime version %q not checked: %s", constraint, err)
+	case !satisfied:
+		return fmt.Sprintf("runtime version %s does not satisfy %q", version, constraint), ""
+	default:
+		return "", ""
+	}
+}
+
+func (v *runtimeVersion) get(ctx context.Context, rt runtime.Runtime) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.done {
+		return v.value, v.err
+	}
+
+	value, err := rt.Version(ctx)
+
+	if err != nil && ctx.Err() != nil {
+		return value, err
+	}
+
+	v.value, v.err, v.done = value, err, true
+
+	return v.value, v.err
+}
+
+func NewTagFilter(include []string, exclude []string) TagFilter {
+	return TagFilter{
+		include: normalizeTags(include),
+		exclude: normalizeTags(exclude),
+	}
+}
+
+// SkipReason returns why a case with the given tags is filtered out, or an
+// empty string when the case is selected.
+func (filter TagFilter) SkipReason(tags []string) string {
+	tags = normalizeTags(tags)
+
+	for _, tag := range filter.exclude {
+		if slices.Contains(tags, tag) {
+			return fmt.Sprintf("excluded by tag %q", tag)
+		}
+	}
+
+	if len(filter.include) == 0 {
+		return ""
+	}
+
+	for _, tag := range filter.include {
+		if slices.Contains(tags, tag) {
+			return ""
+		}
+	}
+
+	quoted := make([]string, len(filter.include))
+
+	for i, tag := range filter.include {
+		quoted[i] = fmt.Sprintf("%q", tag)
+	}
+
+	return fmt.Sprintf("not tagged with %s", strings.Join(quoted, " or "))
+}
+
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+
+	return out
+}
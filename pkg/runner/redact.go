@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+type (
+	// Redactor masks secret values in text reported to the user.
+	Redactor struct {
+		replacer *strings.Replacer
+	}
+
+	redactedError struct {
+		message string
+		err     error
+	}
+)
+
+// RedactedValue replaces every occurrence of a secret value.
+const RedactedValue = "***"
+
+// NewRedactor creates a redactor for the given secret values. Empty values
+// are ignored; a value is also masked in its JSON-escaped form.
+func NewRedactor(secrets []string) *Redactor {
+	values := make([]string, 0, len(secrets))
+	seen := make(map[string]bool, len(secrets))
+
+	add := func(value string) {
+		if value == "" || seen[value] {
+			return
+		}
+
+		seen[value] = true
+		values = append(values, value)
+	}
+
+	for _, secret := range secrets {
+		add(secret)
+
+		if data, err := json.Marshal(secret); err == nil {
+			add(string(data[1 : len(data)-1]))
+		}
+	}
+
+	if len(values) == 0 {
+		return &Redactor{}
+	}
+
+	// longer values first, so that a secret containing another one is masked
+	// as a whole
+	sort.SliceStable(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	pairs := make([]string, 0, len(values)*2)
+
+	for _, value := range values {
+		pairs = append(pairs, value, RedactedValue)
+	}
+
+	return &Redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+// String masks the secret values of the text.
+func (r *Redactor) String(text string) string {
+	if r == nil || r.replacer == nil {
+		return text
+	}
+
+	return r.replacer.Replace(text)
+}
+
+// Error masks the secret values of the error message. The error is returned
+// as is when its message holds no secret; otherwise the returned error still
+// unwraps to it.
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	redacted := r.String(message)
+
+	if redacted == message {
+		return err
+	}
+
+	return &redactedError{message: redacted, err: err}
+}
+
+// Result masks the secret values of the texts of the result.
+func (r *Redactor) Result(res Result) Result {
+	res.Filename = r.String(res.Filename)
+	res.Error = r.Error(res.Error)
+	res.Warning = r.String(res.Warning)
+	res.SkipReason = r.String(res.SkipReason)
+
+	return res
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
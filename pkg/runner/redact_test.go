@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRedactorMasksSecrets(t *testing.T) {
+	redactor := NewRedactor([]string{"abc", "abcdef", `pa"ss`, ""})
+
+	tests := map[string]string{
+		"token abcdef and abc":  "token *** and ***",
+		`{"password":"pa\"ss"}`: `{"password":"***"}`,
+		"password pa\"ss":       "password ***",
+		"nothing to hide":       "nothing to hide",
+	}
+
+	for text, expected := range tests {
+		if actual := redactor.String(text); actual != expected {
+			t.Fatalf("expected %q to be redacted to %q, got %q", text, expected, actual)
+		}
+	}
+}
+
+func TestRedactorKeepsErrorChain(t *testing.T) {
+	redactor := NewRedactor([]string{"abc"})
+	original := errors.Join(context.Canceled, errors.New("token abc"))
+
+	err := redactor.Error(original)
+
+	if err.Error() != "context canceled\ntoken ***" {
+		t.Fatalf("expected a redacted message, got %q", err.Error())
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the redacted error to unwrap to the original error")
+	}
+
+	plain := errors.New("nothing to hide")
+
+	if redactor.Error(plain) != plain {
+		t.Fatalf("expected an error without secrets to be returned as is")
+	}
+
+	if redactor.Error(nil) != nil {
+		t.Fatalf("expected no error")
+	}
+}
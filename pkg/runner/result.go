@@ -10,12 +10,24 @@ type (
 		Duration time.Duration
 		Error    error
 		Warning  string
+		// Skipped marks a case that was not executed; SkipReason explains why.
+		Skipped    bool
+		SkipReason string
+		// Cancelled marks a skipped case that was interrupted or never
+		// dispatched because the run stopped after too many failures.
+		Cancelled bool
 	}
 
 	Summary struct {
-		Passed   int
-		Failed   int
-		Duration time.Duration
+		Passed    int
+		Failed    int
+		Skipped   int
+		Cancelled int
+		Duration  time.Duration
+		// Shard is set when the run executed a single shard of the files.
+		Shard *ShardSummary
+		// Profile is the name of the configuration profile of the run, if any.
+		Profile string
 	}
 )
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MontFerret/lab/v2/pkg/runtime"
@@ -20,20 +21,46 @@ type (
 		Attempts      uint64
 		Times         uint64
 		TimesInterval uint64
+		// Tags selects only cases declaring at least one of the tags.
+		Tags []string
+		// ExcludeTags skips cases declaring any of the tags.
+		ExcludeTags []string
+		// MaxFailures stops the run once that many cases failed; 0 means no limit.
+		MaxFailures uint64
+		// Shard restricts the run to a deterministic subset of the files.
+		Shard *Shard
+		// UpdateSnapshots rewrites suite snapshots instead of comparing them.
+		UpdateSnapshots bool
+		// Profile is the name of the configuration profile the run uses; it is
+		// only reported in the summary.
+		Profile string
+		// Secrets are masked in the reported results.
+		Secrets []string
 	}
 
 	Runner struct {
-		runtime      runtime.Runtime
-		poolSize     uint64
-		testTimeout  time.Duration
-		testAttempts uint64
-		testCount    uint64
-		testInterval uint64
+		runtime         runtime.Runtime
+		poolSize        uint64
+		testTimeout     time.Duration
+		testAttempts    uint64
+		testCount       uint64
+		testInterval    uint64
+		tags            TagFilter
+		version         runtimeVersion
+		maxFailures     uint64
+		shard           *Shard
+		updateSnapshots bool
+		profile         string
+		redactor        *Redactor
 	}
 
 	deprecationWarningCase interface {
 		DeprecationWarning() string
 	}
+
+	multiCase interface {
+		Cases() []testing2.NamedCase
+	}
 )
 
 func New(opts Options) (*Runner, error) {
@@ -66,12 +93,18 @@ func New(opts Options) (*Runner, error) {
 	}
 
 	return &Runner{
-		runtime:      opts.Runtime,
-		poolSize:     poolSize,
-		testAttempts: attempts,
-		testTimeout:  testTimeout,
-		testCount:    times,
-		testInterval: opts.TimesInterval,
+		runtime:         opts.Runtime,
+		poolSize:        poolSize,
+		testAttempts:    attempts,
+		testTimeout:     testTimeout,
+		testCount:       times,
+		testInterval:    opts.TimesInterval,
+		tags:            NewTagFilter(opts.Tags, opts.ExcludeTags),
+		maxFailures:     opts.MaxFailures,
+		shard:           opts.Shard,
+		updateSnapshots: opts.UpdateSnapshots,
+		profile:         opts.Profile,
+		redactor:        NewRedactor(opts.Secrets),
 	}, nil
 }
 
@@ -82,28 +115,50 @@ func (r *Runner) Run(ctx Context, src sources2.Source) Stream {
 	go func() {
 		var failed int
 		var passed int
+		var skipped int
+		var cancelled int
 		startTime := time.Now()
 
 		onNext, onError := src.Read(ctx)
 
+		var counts shardCounts
+
+		if r.shard != nil {
+			onNext = r.shard.filter(ctx, onNext, &counts, r.dependsOn)
+		}
+
 		for res := range r.consume(ctx, onNext, onError) {
-			if res.Error != nil {
+			switch {
+			case res.Cancelled:
+				cancelled++
+			case res.Skipped:
+				skipped++
+			case res.Error != nil:
 				failed++
-			} else {
+			default:
 				passed++
 			}
 
-			onProgress <- res
+			onProgress <- r.redactor.Result(res)
 		}
 
 		close(onProgress)
 
-		onSummary <- Summary{
-			Passed:   passed,
-			Failed:   failed,
-			Duration: time.Since(startTime),
+		summary := Summary{
+			Passed:    passed,
+			Failed:    failed,
+			Skipped:   skipped,
+			Cancelled: cancelled,
+			Duration:  time.Since(startTime),
+			Profile:   r.profile,
+		}
+
+		if r.shard != nil {
+			summary.Shard = r.shard.summary(&counts)
 		}
 
+		onSummary <- summary
+
 		close(onSummary)
 	}()
 
@@ -118,8 +173,77 @@ func (r *Runner) consume(ctx Context, onNext <-chan sources2.File, onError <-cha
 
 	go func() {
 		pool := NewPool(r.poolSize)
+		deps := newDependencyGraph()
 		var wg sync.WaitGroup
 
+		// runCtx is cancelled once the failure limit is reached; the source keeps
+		// being read with ctx so the remaining files can be reported as cancelled.
+		runCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		dirs := r.newDirectories(runCtx, ctx.Params())
+
+		var failures atomic.Uint64
+		var stopped atomic.Bool
+
+		fail := func() {
+			if r.maxFailures > 0 && failures.Add(1) >= r.maxFailures {
+				stopped.Store(true)
+				stop()
+			}
+		}
+
+		// dispatch runs the file on the pool and records its outcome for the
+		// files depending on it. It returns false once ctx is done.
+		dispatch := func(f sources2.File, testCase testing2.Case, params testing2.Params) bool {
+			wg.Add(1)
+
+			if err := pool.GoContext(runCtx, func() {
+				defer wg.Done()
+
+				if ctx.Err() != nil {
+					deps.finish(f.Name, dependencyCancelled)
+					return
+				}
+
+				if stopped.Load() {
+					out <- r.cancelledResult(f.Name)
+					deps.finish(f.Name, dependencyCancelled)
+					return
+				}
+
+				var outcome fileOutcome
+
+				r.runFile(runCtx, dirs, f, testCase, params, func(res Result) {
+					if res.Error != nil && !res.Skipped {
+						// failures after the stop are most likely caused by the cancellation itself
+						if stopped.Load() {
+							res = r.cancelledResult(res.Filename)
+						} else {
+							fail()
+						}
+					}
+
+					outcome.add(res)
+
+					out <- res
+				})
+
+				deps.finish(f.Name, outcome.reason())
+			}); err != nil {
+				wg.Done()
+				deps.finish(f.Name, dependencyCancelled)
+
+				if ctx.Err() != nil {
+					return false
+				}
+
+				out <- r.cancelledResult(f.Name)
+			}
+
+			return true
+		}
+
 	loop:
 		for onNext != nil || onError != nil {
 			select {
@@ -132,29 +256,85 @@ func (r *Runner) consume(ctx Context, onNext <-chan sources2.File, onError <-cha
 				}
 
 				f := file
-				wg.Add(1)
+
+				if stopped.Load() {
+					deps.finish(f.Name, dependencyCancelled)
+					out <- r.cancelledResult(f.Name)
+					continue
+				}
+
+				testCase, err := r.newCase(f)
+
+				if err != nil {
+					fail()
+					deps.finish(f.Name, dependencyFailed)
+
+					out <- Result{
+						Times:    0,
+						Filename: f.Name,
+						Duration: 0,
+						Error:    err,
+					}
+
+					continue
+				}
 
 				params := ctx.Params()
 				params = params.Clone()
 
-				if err := pool.GoContext(ctx, func() {
-					defer wg.Done()
+				var dependsOn []string
+
+				if dependent, ok := testCase.(dependentCase); ok {
+					dependsOn = dependent.DependsOn()
+				}
+
+				deps.add(f.Name, dependsOn)
 
-					if ctx.Err() != nil {
-						return
+				if len(dependsOn) == 0 {
+					if !dispatch(f, testCase, params) {
+						break loop
 					}
 
-					out <- r.runCase(ctx, f, params)
-				}); err != nil {
-					wg.Done()
-					break loop
+					continue
 				}
+
+				// the file waits for its dependencies without holding a worker
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					reason, err := deps.wait(runCtx, dependsOn)
+
+					switch {
+					case deps.finished(f.Name):
+						// failed as part of a dependency cycle
+					case err != nil:
+						deps.finish(f.Name, dependencyCancelled)
+
+						if ctx.Err() == nil {
+							out <- r.cancelledResult(f.Name)
+						}
+					case reason != "":
+						deps.finish(f.Name, dependencySkipped)
+
+						out <- Result{
+							Filename:   f.Name,
+							Skipped:    true,
+							SkipReason: reason,
+						}
+					default:
+						dispatch(f, testCase, params)
+					}
+				}()
 			case err, open := <-onError:
 				if !open {
 					onError = nil
 					continue
 				}
 
+				fail()
+
 				out <- Result{
 					Times:    0,
 					Filename: err.Filename,
@@ -164,35 +344,113 @@ func (r *Runner) consume(ctx Context, onNext <-chan sources2.File, onError <-cha
 			}
 		}
 
+		for _, res := range deps.end() {
+			fail()
+
+			out <- res
+		}
+
 		wg.Wait()
 
+		dirs.finish(ctx, func(res Result) {
+			out <- res
+		})
+
 		close(out)
 	}()
 
 	return out
 }
 
-func (r *Runner) runCase(ctx context.Context, file sources2.File, params testing2.Params) Result {
-	testCase, err := testing2.New(testing2.Options{
-		File:    file,
-		Timeout: r.testTimeout,
-	})
+func (r *Runner) cancelledResult(filename string) Result {
+	return Result{
+		Filename:   filename,
+		Skipped:    true,
+		SkipReason: fmt.Sprintf("cancelled after reaching the failure limit (%d)", r.maxFailures),
+		Cancelled:  true,
+	}
+}
 
+// dependsOn returns the names of the files the file depends on; a file that
+// fails to parse is reported when it runs and has no dependencies here.
+func (r *Runner) dependsOn(file sources2.File) []string {
+	testCase, err := r.newCase(file)
 	if err != nil {
-		return Result{
-			Times:    0,
-			Filename: file.Name,
-			Duration: 0,
-			Error:    err,
+		return nil
+	}
+
+	if dependent, ok := testCase.(dependentCase); ok {
+		return dependent.DependsOn()
+	}
+
+	return nil
+}
+
+func (r *Runner) newCase(file sources2.File) (testing2.Case, error) {
+	return testing2.New(testing2.Options{
+		File:            file,
+		Timeout:         r.testTimeout,
+		UpdateSnapshots: r.updateSnapshots,
+	})
+}
+
+// runFile emits one result per case declared by the file.
+func (r *Runner) runFile(ctx context.Context, dirs *directories, file sources2.File, testCase testing2.Case, params testing2.Params, emit func(Result)) {
+	if multi, ok := testCase.(multiCase); ok {
+		if cases := multi.Cases(); cases != nil {
+			for _, c := range cases {
+				if err := ctx.Err(); err != nil {
+					emit(Result{Filename: c.Name, Error: err})
+
+					continue
+				}
+
+				emit(r.runCase(ctx, dirs, file.Configs, c.Name, c.Case, params.Clone()))
+			}
+
+			return
 		}
 	}
 
+	emit(r.runCase(ctx, dirs, file.Configs, file.Name, testCase, params))
+}
+
+func (r *Runner) runCase(ctx context.Context, dirs *directories, configs []sources2.File, name string, testCase testing2.Case, params testing2.Params) Result {
+	var err error
 	var warning string
 
 	if deprecated, ok := testCase.(deprecationWarningCase); ok {
 		warning = deprecated.DeprecationWarning()
 	}
 
+	reason, versionWarning := r.skipReason(ctx, testCase)
+
+	switch {
+	case warning == "":
+		warning = versionWarning
+	case versionWarning != "":
+		warning += "; " + versionWarning
+	}
+
+	// skipped cases are reported rather than dropped so that the selection is visible
+	if reason != "" {
+		return Result{
+			Filename:   name,
+			Warning:    warning,
+			Skipped:    true,
+			SkipReason: reason,
+		}
+	}
+
+	params, err = dirs.prepare(ctx, configs, params)
+	if err != nil {
+		return Result{
+			Filename: name,
+			Warning:  warning,
+			Error:    err,
+		}
+	}
+
 	attemptCounter := uint64(0)
 	runCounter := uint64(0)
 	totalDuration := int64(0)
@@ -243,7 +501,7 @@ loop:
 	return Result{
 		Times:    runCounter,
 		Attempts: attemptCounter,
-		Filename: file.Name,
+		Filename: name,
 		Duration: time.Duration(totalDuration / int64(runCounter)), // average duration
 		Error:    err,
 		Warning:  warning,
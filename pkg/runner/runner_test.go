@@ -3,7 +3,10 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -165,3 +168,747 @@ func TestRunnerReportsLegacyExpectedFailureDeprecationOnce(t *testing.T) {
 		t.Fatalf("expected exactly two runtime invocations, got %d", got)
 	}
 }
+
+func TestTagFilterSkipReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		tags     []string
+		expected string
+	}{
+		{name: "no filter", tags: []string{"smoke"}},
+		{name: "no filter untagged"},
+		{name: "included", include: []string{"smoke", "api"}, tags: []string{"api"}},
+		{name: "not included", include: []string{"smoke", "api"}, tags: []string{"slow"}, expected: `not tagged with "smoke" or "api"`},
+		{name: "untagged not included", include: []string{"smoke"}, expected: `not tagged with "smoke"`},
+		{name: "excluded", exclude: []string{"slow"}, tags: []string{"smoke", "slow"}, expected: `excluded by tag "slow"`},
+		{name: "exclude wins", include: []string{"smoke"}, exclude: []string{"slow"}, tags: []string{"smoke", "slow"}, expected: `excluded by tag "slow"`},
+		{name: "trims values", include: []string{" smoke "}, tags: []string{"smoke"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewTagFilter(test.include, test.exclude).SkipReason(test.tags)
+
+			if got != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunnerReportsTagFilteredSuitesAsSkipped(t *testing.T) {
+	var calls atomic.Int32
+
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		calls.Add(1)
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{
+		Runtime:     rt,
+		ExcludeTags: []string{"slow"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stream := r.Run(NewContext(context.Background(), testing2.NewParams()), singleFileSource{
+		file: sources.File{
+			Name: "slow.yaml",
+			Content: []byte(`
+tags: [smoke, slow]
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`),
+		},
+	})
+
+	result := <-stream.Progress
+
+	if !result.Skipped || result.SkipReason != `excluded by tag "slow"` {
+		t.Fatalf("expected skipped result, got %+v", result)
+	}
+
+	if result.Error != nil {
+		t.Fatalf("expected no error, got %v", result.Error)
+	}
+
+	summary := <-stream.Summary
+	if summary.Passed != 0 || summary.Failed != 0 || summary.Skipped != 1 || summary.HasErrors() {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("expected no runtime invocations, got %d", got)
+	}
+}
+
+type versionedRuntime struct {
+	labruntime.Runtime
+	version string
+}
+
+func (rt versionedRuntime) Version(_ context.Context) (string, error) {
+	return rt.version, nil
+}
+
+func TestRunnerSkipsSuitesByManifestAndRuntimeVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		version  string
+		reason   string
+		warning  string
+	}{
+		{
+			name:     "manifest skip",
+			manifest: "skip: flaky upstream\n",
+			version:  "2.0.0",
+			reason:   "flaky upstream",
+		},
+		{
+			name:     "unmet runtime version",
+			manifest: "runtimeVersion: \">=2.1.0\"\n",
+			version:  "2.0.0",
+			reason:   `runtime version 2.0.0 does not satisfy ">=2.1.0"`,
+		},
+		{
+			name:     "unknown runtime version",
+			manifest: "runtimeVersion: \">=2.1.0\"\n",
+			version:  "unknown",
+			warning:  `runtime version ">=2.1.0" not checked: cannot determine version from "unknown"`,
+		},
+		{
+			name:     "met runtime version",
+			manifest: "runtimeVersion: \">=2.1.0\"\n",
+			version:  "v2.1.3",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			rt := versionedRuntime{
+				Runtime: labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+					calls.Add(1)
+
+					return []byte(`true`), nil
+				}),
+				version: test.version,
+			}
+
+			r, err := New(Options{Runtime: rt})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			stream := r.Run(NewContext(context.Background(), testing2.NewParams()), singleFileSource{
+				file: sources.File{
+					Name:    "suite.yaml",
+					Content: []byte(test.manifest + "query:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+				},
+			})
+
+			result := <-stream.Progress
+			summary := <-stream.Summary
+
+			if result.Error != nil {
+				t.Fatalf("expected no error, got %v", result.Error)
+			}
+
+			if test.reason == "" {
+				if result.Skipped || summary.Passed != 1 || calls.Load() != 2 {
+					t.Fatalf("expected suite to run, got %+v and %+v", result, summary)
+				}
+
+				if result.Warning != test.warning {
+					t.Fatalf("expected warning %q, got %q", test.warning, result.Warning)
+				}
+
+				return
+			}
+
+			if !result.Skipped || result.SkipReason != test.reason {
+				t.Fatalf("expected skip reason %q, got %+v", test.reason, result)
+			}
+
+			if summary.Skipped != 1 || summary.HasErrors() || calls.Load() != 0 {
+				t.Fatalf("expected one skipped result without runs, got %+v", summary)
+			}
+		})
+	}
+}
+
+type lookupRuntime struct {
+	labruntime.Runtime
+	calls atomic.Int32
+}
+
+func (rt *lookupRuntime) Version(ctx context.Context) (string, error) {
+	rt.calls.Add(1)
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "2.1.0", nil
+}
+
+func TestRuntimeVersionRetriesInterruptedLookup(t *testing.T) {
+	rt := &lookupRuntime{}
+
+	var version runtimeVersion
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := version.get(ctx, rt); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled lookup, got %v", err)
+	}
+
+	for range 2 {
+		value, err := version.get(context.Background(), rt)
+		if err != nil || value != "2.1.0" {
+			t.Fatalf("expected the version to be looked up again, got %q, %v", value, err)
+		}
+	}
+
+	if calls := rt.calls.Load(); calls != 2 {
+		t.Fatalf("expected the version to be cached after a completed lookup, got %d lookups", calls)
+	}
+}
+
+type filesSource struct {
+	files []sources.File
+}
+
+func (s filesSource) Read(_ context.Context) (<-chan sources.File, <-chan sources.Error) {
+	onNext := make(chan sources.File)
+	onError := make(chan sources.Error)
+
+	go func() {
+		for _, file := range s.files {
+			onNext <- file
+		}
+
+		close(onNext)
+		close(onError)
+	}()
+
+	return onNext, onError
+}
+
+func (s filesSource) Resolve(_ context.Context, _ *url.URL) (<-chan sources.File, <-chan sources.Error) {
+	return singleFileSource{}.Resolve(context.Background(), nil)
+}
+
+func TestRunnerStopsAfterMaxFailures(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+
+	rt := labruntime.AsFunc(func(ctx context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		calls.Add(1)
+
+		switch query.Content() {
+		case "RETURN 1":
+			return []byte(`1`), nil
+		case "WAIT":
+			close(started)
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		default:
+			// fail only once the slow test is in flight so that it has to be cancelled
+			<-started
+
+			return nil, errors.New("broken environment")
+		}
+	})
+
+	r, err := New(Options{
+		Runtime:     rt,
+		PoolSize:    2,
+		MaxFailures: 1,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	files := []sources.File{
+		{Name: "slow.fql", Content: []byte("WAIT")},
+		{Name: "broken.fql", Content: []byte("FAIL")},
+	}
+
+	for i := 0; i < 5; i++ {
+		files = append(files, sources.File{Name: "pending.fql", Content: []byte("RETURN 1")})
+	}
+
+	stream := r.Run(NewContext(context.Background(), testing2.NewParams()), filesSource{files: files})
+
+	results := make(map[string][]Result)
+
+	for res := range stream.Progress {
+		results[res.Filename] = append(results[res.Filename], res)
+	}
+
+	summary := <-stream.Summary
+
+	if summary.Failed != 1 || summary.Passed != 0 || summary.Cancelled != 6 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	if res := results["broken.fql"]; len(res) != 1 || res[0].Error == nil {
+		t.Fatalf("expected broken.fql to fail, got %+v", res)
+	}
+
+	if res := results["slow.fql"]; len(res) != 1 || !res[0].Cancelled || res[0].Error != nil {
+		t.Fatalf("expected in-flight slow.fql to be cancelled, got %+v", res)
+	}
+
+	for _, res := range results["pending.fql"] {
+		if !res.Cancelled || !res.Skipped || res.SkipReason != "cancelled after reaching the failure limit (1)" {
+			t.Fatalf("expected pending file to be cancelled, got %+v", res)
+		}
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected only the dispatched files to run, got %d runtime invocations", got)
+	}
+}
+
+func TestRunnerReportsEachSuiteCase(t *testing.T) {
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		if query.Content() == "RETURN false" {
+			return nil, errors.New("assertion failed")
+		}
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{Runtime: rt, ExcludeTags: []string{"slow"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stream := r.Run(NewContext(context.Background(), testing2.NewParams()), singleFileSource{
+		file: sources.File{
+			Name: "page.yaml",
+			Content: []byte(`
+query:
+  text: RETURN 1
+cases:
+  - name: passes
+    assert:
+      text: RETURN true
+  - name: fails
+    assert:
+      text: RETURN false
+  - name: slow
+    tags: [slow]
+    assert:
+      text: RETURN true
+`),
+		},
+	})
+
+	results := make(map[string]Result)
+
+	for res := range stream.Progress {
+		results[res.Filename] = res
+	}
+
+	summary := <-stream.Summary
+
+	if summary.Passed != 1 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	if res, ok := results["page.yaml#passes"]; !ok || res.Error != nil {
+		t.Fatalf("expected page.yaml#passes to pass, got %+v", res)
+	}
+
+	if res, ok := results["page.yaml#fails"]; !ok || res.Error == nil {
+		t.Fatalf("expected page.yaml#fails to fail, got %+v", res)
+	}
+
+	if res, ok := results["page.yaml#slow"]; !ok || !res.Skipped {
+		t.Fatalf("expected page.yaml#slow to be skipped, got %+v", res)
+	}
+}
+
+func TestRunnerRunsDirectoryHooks(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	var seen []map[string]any
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls = append(calls, query.Content())
+
+		switch query.Content() {
+		case "BEFORE":
+			return []byte(`{"token":"abc"}`), nil
+		case "TEST":
+			seen = append(seen, params)
+		}
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{Runtime: rt, PoolSize: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config := sources.File{
+		Name: "e2e/_lab.yaml",
+		Content: []byte(`
+beforeAll:
+  text: BEFORE
+afterAll:
+  text: AFTER
+params:
+  baseUrl: http://dir.test
+  retries: 3
+`),
+	}
+
+	files := []sources.File{
+		{Name: "e2e/a.fql", Content: []byte("TEST"), Configs: []sources.File{config}},
+		{Name: "e2e/b.fql", Content: []byte("TEST"), Configs: []sources.File{config}},
+	}
+
+	params := testing2.NewParams()
+	params.SetUserValue("baseUrl", "http://cli.test")
+
+	stream := r.Run(NewContext(context.Background(), params), filesSource{files: files})
+
+	for res := range stream.Progress {
+		if res.Error != nil {
+			t.Fatalf("expected %s to pass, got %v", res.Filename, res.Error)
+		}
+	}
+
+	if summary := <-stream.Summary; summary.Passed != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	if len(calls) != 4 || calls[0] != "BEFORE" || calls[3] != "AFTER" {
+		t.Fatalf("expected beforeAll once before the tests and afterAll once after them, got %v", calls)
+	}
+
+	for _, p := range seen {
+		if p["baseUrl"] != "http://cli.test" || p["retries"] != 3 {
+			t.Fatalf("expected run params to override directory params, got %v", p)
+		}
+
+		data := p["lab"].(map[string]any)["data"].(map[string]any)
+		result := data["beforeAll"].(map[string]any)["result"]
+
+		if !reflect.DeepEqual(result, map[string]any{"token": "abc"}) {
+			t.Fatalf("expected beforeAll result to be exposed, got %v", result)
+		}
+	}
+}
+
+func TestRunnerFailsTestsOfBrokenDirectory(t *testing.T) {
+	var afterAll atomic.Int32
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		switch query.Content() {
+		case "BEFORE":
+			return nil, errors.New("no database")
+		case "AFTER":
+			afterAll.Add(1)
+		}
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{Runtime: rt})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config := sources.File{
+		Name:    "e2e/_lab.yaml",
+		Content: []byte("beforeAll:\n  text: BEFORE\nafterAll:\n  text: AFTER\n"),
+	}
+
+	stream := r.Run(NewContext(context.Background(), testing2.NewParams()), singleFileSource{
+		file: sources.File{Name: "e2e/a.fql", Content: []byte("TEST"), Configs: []sources.File{config}},
+	})
+
+	res := <-stream.Progress
+
+	if res.Error == nil || res.Error.Error() != "beforeAll e2e/_lab.yaml: failed to execute beforeAll script: no database" {
+		t.Fatalf("expected the test to fail with the beforeAll error, got %v", res.Error)
+	}
+
+	for range stream.Progress {
+	}
+
+	<-stream.Summary
+
+	// afterAll tears down what a successful beforeAll set up
+	if calls := afterAll.Load(); calls != 0 {
+		t.Fatalf("expected afterAll not to run after a failed beforeAll, ran %d times", calls)
+	}
+}
+
+func TestRunnerSchedulesDependencies(t *testing.T) {
+	suite := func(name string, query string, dependsOn ...string) sources.File {
+		content := "query:\n  text: " + query + "\nassert:\n  text: RETURN true\n"
+
+		for i, dep := range dependsOn {
+			if i == 0 {
+				content += "dependsOn:\n"
+			}
+
+			content += "  - " + dep + "\n"
+		}
+
+		return sources.File{Name: name, Content: []byte(content)}
+	}
+
+	var mu sync.Mutex
+	var order []string
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		if query.Content() == "RETURN true" {
+			return []byte(`true`), nil
+		}
+
+		mu.Lock()
+		order = append(order, query.Content())
+		mu.Unlock()
+
+		if query.Content() == "FAIL" {
+			return nil, errors.New("payment declined")
+		}
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{Runtime: rt, PoolSize: 4})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stream := r.Run(NewContext(context.Background(), testing2.NewParams()), filesSource{files: []sources.File{
+		// dependents are read before their dependencies on purpose
+		suite("flow/login.yaml", "LOGIN", "./account.yaml"),
+		suite("flow/purchase.yaml", "FAIL", "login.yaml"),
+		suite("flow/receipt.yaml", "RECEIPT", "./purchase.yaml"),
+		suite("flow/account.yaml", "ACCOUNT"),
+		suite("flow/orphan.yaml", "ORPHAN", "./missing.yaml"),
+		suite("flow/a.yaml", "A", "./b.yaml"),
+		suite("flow/b.yaml", "B", "./a.yaml"),
+	}})
+
+	results := make(map[string]Result)
+
+	for res := range stream.Progress {
+		results[res.Filename] = res
+	}
+
+	summary := <-stream.Summary
+
+	if summary.Passed != 2 || summary.Failed != 3 || summary.Skipped != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	if len(order) != 3 || order[0] != "ACCOUNT" || order[1] != "LOGIN" || order[2] != "FAIL" {
+		t.Fatalf("expected dependencies to run first, got %v", order)
+	}
+
+	for name, reason := range map[string]string{
+		"flow/receipt.yaml": "dependency flow/purchase.yaml failed",
+		"flow/orphan.yaml":  "dependency flow/missing.yaml is not part of the run",
+	} {
+		if res := results[name]; !res.Skipped || res.SkipReason != reason {
+			t.Fatalf("expected %s to be skipped with %q, got %+v", name, reason, res)
+		}
+	}
+
+	for _, name := range []string{"flow/a.yaml", "flow/b.yaml"} {
+		expected := "dependency cycle: flow/a.yaml -> flow/b.yaml -> flow/a.yaml"
+
+		if res := results[name]; res.Error == nil || res.Error.Error() != expected {
+			t.Fatalf("expected %s to fail with %q, got %+v", name, expected, res)
+		}
+	}
+}
+
+func TestRunnerShardsKeepDependenciesTogether(t *testing.T) {
+	files := make([]sources.File, 0, 12)
+
+	for i := 0; i < 12; i++ {
+		content := "query:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"
+
+		// every suite depends on the previous one
+		if i > 0 {
+			content += fmt.Sprintf("dependsOn: [./suite-%02d.yaml]\n", i-1)
+		}
+
+		files = append(files, sources.File{Name: fmt.Sprintf("flow/suite-%02d.yaml", i), Content: []byte(content)})
+	}
+
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		return []byte(`true`), nil
+	})
+
+	passed := 0
+
+	for index := uint64(1); index <= 3; index++ {
+		shard, err := NewShard(index, 3, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		r, err := New(Options{Runtime: rt, PoolSize: 4, Shard: shard})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		stream := r.Run(NewContext(context.Background(), testing2.NewParams()), filesSource{files: files})
+
+		for res := range stream.Progress {
+			if res.Skipped || res.Error != nil {
+				t.Fatalf("shard %d: expected %s to pass, got %+v", index, res.Filename, res)
+			}
+
+			passed++
+		}
+
+		<-stream.Summary
+	}
+
+	if passed != len(files) {
+		t.Fatalf("expected every suite to pass in one of the shards, got %d", passed)
+	}
+}
+
+func TestRunnerRedactsSecretsInResults(t *testing.T) {
+	var received any
+
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, params map[string]any) ([]byte, error) {
+		received = params["token"]
+
+		return nil, errors.New(`request failed: Authorization "Bearer s3cr3t" rejected`)
+	})
+
+	r, err := New(Options{
+		Runtime: rt,
+		Secrets: []string{"s3cr3t"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	params := testing2.NewParams()
+	params.SetUserValue("token", "s3cr3t")
+
+	stream := r.Run(NewContext(context.Background(), params), singleFileSource{
+		file: sources.File{
+			Name:    "test.fql",
+			Content: []byte("RETURN @token"),
+		},
+	})
+
+	result := <-stream.Progress
+	<-stream.Summary
+
+	if received != "s3cr3t" {
+		t.Fatalf("expected the runtime to receive the secret unchanged, got %v", received)
+	}
+
+	if result.Error == nil || result.Error.Error() != `request failed: Authorization "Bearer ***" rejected` {
+		t.Fatalf("expected the secret to be redacted, got %v", result.Error)
+	}
+}
+
+func TestDirectoriesKeepTestParams(t *testing.T) {
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		return []byte(`{"token":"abc"}`), nil
+	})
+
+	r, err := New(Options{Runtime: rt})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config := sources.File{
+		Name:    "e2e/_lab.yaml",
+		Content: []byte("beforeAll:\n  text: BEFORE\nparams:\n  baseUrl: http://dir.test\n  user: dir\n"),
+	}
+
+	dirs := r.newDirectories(context.Background(), testing2.NewParams())
+
+	params := testing2.NewParams()
+	params.SetUserValue("user", "case")
+
+	prepared, err := dirs.prepare(context.Background(), []sources.File{config}, params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	values := prepared.ToMap()
+
+	if values["user"] != "case" || values["baseUrl"] != "http://dir.test" {
+		t.Fatalf("expected the test params completed with the directory params, got %v", values)
+	}
+
+	data := values["lab"].(map[string]any)["data"].(map[string]any)
+
+	if _, ok := data["beforeAll"]; !ok {
+		t.Fatalf("expected beforeAll data to be exposed, got %v", data)
+	}
+}
+
+func TestDirectoriesDoNotShareCancellationOfFirstTest(t *testing.T) {
+	release := make(chan struct{})
+
+	rt := labruntime.AsFunc(func(ctx context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		<-release
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return []byte(`1`), nil
+	})
+
+	r, err := New(Options{Runtime: rt})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config := sources.File{
+		Name:    "e2e/_lab.yaml",
+		Content: []byte("beforeAll:\n  text: BEFORE\n"),
+	}
+
+	dirs := r.newDirectories(context.Background(), testing2.NewParams())
+
+	first, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dirs.prepare(first, []sources.File{config}, testing2.NewParams()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first test to be cancelled, got %v", err)
+	}
+
+	close(release)
+
+	if _, err := dirs.prepare(context.Background(), []sources.File{config}, testing2.NewParams()); err != nil {
+		t.Fatalf("expected beforeAll to outlive the first test, got %v", err)
+	}
+}
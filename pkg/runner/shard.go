@@ -0,0 +1,373 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sources2 "github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+type (
+	// Shard selects a deterministic subset of the discovered files so that a
+	// run can be split across machines. Files that depend on each other form
+	// a group that is assigned as a whole. Without timings a group is assigned
+	// by a stable hash of its name; with timings groups are balanced by their
+	// historical duration.
+	Shard struct {
+		index   uint64
+		count   uint64
+		timings map[string]time.Duration
+		root    string
+	}
+
+	// ShardSummary reports which shard was executed and how many of the
+	// discovered files were assigned to it.
+	ShardSummary struct {
+		Index      uint64
+		Count      uint64
+		Assigned   int
+		Discovered int
+	}
+
+	// shardGroup holds the indexes of files that have to run in the same
+	// shard because they depend on each other.
+	shardGroup struct {
+		name  string
+		files []int
+	}
+
+	shardCounts struct {
+		assigned   atomic.Int64
+		discovered atomic.Int64
+	}
+)
+
+// ParseShard parses a 1-based "index/count" value such as "2/5".
+func ParseShard(value string) (uint64, uint64, error) {
+	indexText, countText, ok := strings.Cut(strings.TrimSpace(value), "/")
+
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected <index>/<count>", value)
+	}
+
+	index, err := strconv.ParseUint(strings.TrimSpace(indexText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected <index>/<count>", value)
+	}
+
+	count, err := strconv.ParseUint(strings.TrimSpace(countText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected <index>/<count>", value)
+	}
+
+	if count == 0 || index == 0 || index > count {
+		return 0, 0, fmt.Errorf("invalid shard %q: index must be between 1 and %d", value, count)
+	}
+
+	return index, count, nil
+}
+
+// NewShard creates the index-th (1-based) of count shards. Timings map file
+// or case names to their historical durations and may be nil.
+func NewShard(index uint64, count uint64, timings map[string]time.Duration) (*Shard, error) {
+	if count == 0 || index == 0 || index > count {
+		return nil, errors.New("shard index must be between 1 and the shard count")
+	}
+
+	root, _ := os.Getwd()
+
+	shard := &Shard{
+		index: index,
+		count: count,
+		root:  root,
+	}
+
+	if len(timings) > 0 {
+		shard.timings = make(map[string]time.Duration, len(timings))
+
+		for name, duration := range timings {
+			shard.timings[shard.key(name)] = duration
+		}
+	}
+
+	return shard, nil
+}
+
+// filter forwards the files assigned to the shard and counts the discovered
+// and assigned files. Files linked by dependencies are assigned to the same
+// shard, so every file is read before the first one is forwarded. dependsOn
+// returns the names of the files a file depends on and may be nil.
+func (shard *Shard) filter(ctx context.Context, onNext <-chan sources2.File, counts *shardCounts, dependsOn func(sources2.File) []string) <-chan sources2.File {
+	out := make(chan sources2.File)
+
+	go func() {
+		defer close(out)
+
+		files := make([]sources2.File, 0)
+
+		for file := range onNext {
+			files = append(files, file)
+			counts.discovered.Add(1)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		groups := shard.groups(files, dependsOn)
+
+		var assigned []bool
+
+		if shard.timings != nil {
+			assigned = shard.balance(files, groups)
+		} else {
+			assigned = shard.hash(files, groups)
+		}
+
+		// keep the discovery order for the files of this shard
+		for i, file := range files {
+			if !assigned[i] {
+				continue
+			}
+
+			if !shard.send(ctx, out, file) {
+				return
+			}
+
+			counts.assigned.Add(1)
+		}
+	}()
+
+	return out
+}
+
+// groups returns the indexes of the files linked by dependencies, directly or
+// transitively. A file without dependencies and dependents is a group of its
+// own. Every group is named after its smallest file key.
+func (shard *Shard) groups(files []sources2.File, dependsOn func(sources2.File) []string) []shardGroup {
+	parents := make([]int, len(files))
+	indexes := make(map[string]int, len(files))
+
+	for i, file := range files {
+		parents[i] = i
+		indexes[shard.key(file.Name)] = i
+	}
+
+	var root func(i int) int
+
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+
+		return parents[i]
+	}
+
+	if dependsOn != nil {
+		for i, file := range files {
+			for _, dep := range dependsOn(file) {
+				if j, found := indexes[shard.key(dep)]; found {
+					parents[root(j)] = root(i)
+				}
+			}
+		}
+	}
+
+	byRoot := make(map[int]int, len(files))
+	groups := make([]shardGroup, 0, len(files))
+
+	for i, file := range files {
+		r := root(i)
+		key := shard.key(file.Name)
+
+		g, found := byRoot[r]
+
+		if !found {
+			g = len(groups)
+			byRoot[r] = g
+			groups = append(groups, shardGroup{name: key})
+		}
+
+		groups[g].files = append(groups[g].files, i)
+
+		if key < groups[g].name {
+			groups[g].name = key
+		}
+	}
+
+	return groups
+}
+
+// hash assigns every group by a stable hash of its name.
+func (shard *Shard) hash(files []sources2.File, groups []shardGroup) []bool {
+	assigned := make([]bool, len(files))
+
+	for _, g := range groups {
+		if fnvHash(g.name)%shard.count != shard.index-1 {
+			continue
+		}
+
+		for _, i := range g.files {
+			assigned[i] = true
+		}
+	}
+
+	return assigned
+}
+
+// balance assigns the groups greedily, longest first, to the least loaded
+// shard. Files without a recorded duration count as the average of the files
+// with one.
+func (shard *Shard) balance(files []sources2.File, groups []shardGroup) []bool {
+	durations := shard.durations(files)
+	recorded := 0
+
+	var total time.Duration
+
+	for _, duration := range durations {
+		if duration > 0 {
+			total += duration
+			recorded++
+		}
+	}
+
+	fallback := time.Duration(0)
+
+	if recorded > 0 {
+		fallback = total / time.Duration(recorded)
+	}
+
+	totals := make([]time.Duration, len(groups))
+	order := make([]int, len(groups))
+
+	for g, group := range groups {
+		order[g] = g
+
+		for _, i := range group.files {
+			if durations[i] > 0 {
+				totals[g] += durations[i]
+			} else {
+				totals[g] += fallback
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := order[a], order[b]
+
+		if totals[x] != totals[y] {
+			return totals[x] > totals[y]
+		}
+
+		return groups[x].name < groups[y].name
+	})
+
+	loads := make([]time.Duration, shard.count)
+	assigned := make([]bool, len(files))
+
+	for _, g := range order {
+		target := 0
+
+		for s := range loads {
+			if loads[s] < loads[target] {
+				target = s
+			}
+		}
+
+		loads[target] += totals[g]
+
+		if uint64(target) == shard.index-1 {
+			for _, i := range groups[g].files {
+				assigned[i] = true
+			}
+		}
+	}
+
+	return assigned
+}
+
+// durations returns the recorded duration of every file, or zero when the
+// file has no history. The results of the cases of a suite are reported as
+// "<file>#<case>" or "<file>[<combination>]"; their durations are summed up
+// for the file.
+func (shard *Shard) durations(files []sources2.File) []time.Duration {
+	indexes := make(map[string]int, len(files))
+
+	for i, file := range files {
+		indexes[shard.key(file.Name)] = i
+	}
+
+	durations := make([]time.Duration, len(files))
+
+	for name, duration := range shard.timings {
+		if i, found := indexes[name]; found {
+			durations[i] += duration
+
+			continue
+		}
+
+		// the longest discovered file the case name starts with
+		for end := len(name) - 1; end > 0; end-- {
+			if name[end] != '#' && name[end] != '[' {
+				continue
+			}
+
+			if i, found := indexes[name[:end]]; found {
+				durations[i] += duration
+
+				break
+			}
+		}
+	}
+
+	return durations
+}
+
+func (shard *Shard) summary(counts *shardCounts) *ShardSummary {
+	return &ShardSummary{
+		Index:      shard.index,
+		Count:      shard.count,
+		Assigned:   int(counts.assigned.Load()),
+		Discovered: int(counts.discovered.Load()),
+	}
+}
+
+func (shard *Shard) send(ctx context.Context, out chan<- sources2.File, file sources2.File) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- file:
+		return true
+	}
+}
+
+// key makes file names under the working directory relative so that every
+// machine computes the same assignment regardless of its checkout location.
+func (shard *Shard) key(name string) string {
+	if shard.root == "" || !filepath.IsAbs(name) {
+		return filepath.ToSlash(name)
+	}
+
+	rel, err := filepath.Rel(shard.root, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(name)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func fnvHash(value string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+
+	return h.Sum64()
+}
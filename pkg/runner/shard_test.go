@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sources2 "github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+func TestParseShard(t *testing.T) {
+	index, count, err := ParseShard(" 2/5 ")
+	if err != nil || index != 2 || count != 5 {
+		t.Fatalf("expected 2/5, got %d/%d (%v)", index, count, err)
+	}
+
+	for _, value := range []string{"", "2", "0/3", "4/3", "1/0", "a/3", "1/b", "-1/3"} {
+		if _, _, err := ParseShard(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestShardAssignsEveryFileToExactlyOneShard(t *testing.T) {
+	files := make([]sources2.File, 0, 50)
+
+	for i := 0; i < 50; i++ {
+		files = append(files, sources2.File{Name: fmt.Sprintf("tests/case-%02d.fql", i)})
+	}
+
+	timings := map[string]time.Duration{
+		"tests/case-00.fql": 30 * time.Second,
+		"tests/case-01.fql": 20 * time.Second,
+		"tests/case-02.fql": 10 * time.Second,
+	}
+
+	for _, withTimings := range []bool{false, true} {
+		t.Run(fmt.Sprintf("timings=%t", withTimings), func(t *testing.T) {
+			seen := make(map[string]int)
+			total := 0
+
+			for index := uint64(1); index <= 3; index++ {
+				var shardTimings map[string]time.Duration
+
+				if withTimings {
+					shardTimings = timings
+				}
+
+				shard, err := NewShard(index, 3, shardTimings)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				var counts shardCounts
+
+				for file := range shard.filter(context.Background(), fileChannel(files), &counts, nil) {
+					seen[file.Name]++
+				}
+
+				summary := shard.summary(&counts)
+
+				if summary.Discovered != len(files) || summary.Index != index || summary.Count != 3 {
+					t.Fatalf("unexpected shard summary: %+v", summary)
+				}
+
+				total += summary.Assigned
+			}
+
+			if total != len(files) || len(seen) != len(files) {
+				t.Fatalf("expected every file once, assigned %d, seen %d", total, len(seen))
+			}
+
+			for name, count := range seen {
+				if count != 1 {
+					t.Fatalf("expected %s once, got %d", name, count)
+				}
+			}
+		})
+	}
+}
+
+func TestShardBalancesByTimings(t *testing.T) {
+	files := []sources2.File{
+		{Name: "a.fql"},
+		{Name: "b.fql"},
+		{Name: "c.fql"},
+		{Name: "d.fql"},
+	}
+
+	timings := map[string]time.Duration{
+		"a.fql": 10 * time.Second,
+		"b.fql": 6 * time.Second,
+		"c.fql": 4 * time.Second,
+		"d.fql": 1 * time.Second,
+	}
+
+	expected := map[uint64][]string{
+		1: {"a.fql", "d.fql"},
+		2: {"b.fql", "c.fql"},
+	}
+
+	for index, names := range expected {
+		shard, err := NewShard(index, 2, timings)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var counts shardCounts
+		got := make([]string, 0)
+
+		for file := range shard.filter(context.Background(), fileChannel(files), &counts, nil) {
+			got = append(got, file.Name)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(names) {
+			t.Fatalf("shard %d: expected %v, got %v", index, names, got)
+		}
+	}
+}
+
+func fileChannel(files []sources2.File) <-chan sources2.File {
+	out := make(chan sources2.File, len(files))
+
+	for _, file := range files {
+		out <- file
+	}
+
+	close(out)
+
+	return out
+}
+
+func TestShardBalancesCaseTimingsByFile(t *testing.T) {
+	files := []sources2.File{
+		{Name: "cases.yaml"},
+		{Name: "matrix.yaml"},
+		{Name: "a.fql"},
+		{Name: "b.fql"},
+	}
+
+	// the json reporter records a result per case and combination
+	timings := map[string]time.Duration{
+		"cases.yaml#login":            4 * time.Second,
+		"cases.yaml#logout":           4 * time.Second,
+		"matrix.yaml[locale=en]":      3 * time.Second,
+		"matrix.yaml#home[locale=de]": 3 * time.Second,
+		"a.fql":                       5 * time.Second,
+		"b.fql":                       1 * time.Second,
+	}
+
+	expected := map[uint64][]string{
+		1: {"cases.yaml", "b.fql"},
+		2: {"matrix.yaml", "a.fql"},
+	}
+
+	for index, names := range expected {
+		shard, err := NewShard(index, 2, timings)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var counts shardCounts
+		got := make([]string, 0)
+
+		for file := range shard.filter(context.Background(), fileChannel(files), &counts, nil) {
+			got = append(got, file.Name)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(names) {
+			t.Fatalf("shard %d: expected %v, got %v", index, names, got)
+		}
+	}
+}
+
+func TestShardKeepsDependenciesTogether(t *testing.T) {
+	files := make([]sources2.File, 0, 20)
+
+	for i := 0; i < 20; i++ {
+		files = append(files, sources2.File{Name: fmt.Sprintf("tests/suite-%02d.yaml", i)})
+	}
+
+	// 05 -> 03 -> 17 and 11 -> 17 form one chain, 08 -> 09 another
+	deps := map[string][]string{
+		"tests/suite-05.yaml": {"tests/suite-03.yaml"},
+		"tests/suite-03.yaml": {"tests/suite-17.yaml"},
+		"tests/suite-11.yaml": {"tests/suite-17.yaml"},
+		"tests/suite-08.yaml": {"tests/suite-09.yaml"},
+	}
+
+	dependsOn := func(file sources2.File) []string {
+		return deps[file.Name]
+	}
+
+	chains := [][]string{
+		{"tests/suite-03.yaml", "tests/suite-05.yaml", "tests/suite-11.yaml", "tests/suite-17.yaml"},
+		{"tests/suite-08.yaml", "tests/suite-09.yaml"},
+	}
+
+	timings := map[string]time.Duration{
+		"tests/suite-05.yaml": 10 * time.Second,
+		"tests/suite-09.yaml": 8 * time.Second,
+	}
+
+	for _, withTimings := range []bool{false, true} {
+		t.Run(fmt.Sprintf("timings=%t", withTimings), func(t *testing.T) {
+			shards := make(map[string]uint64)
+
+			for index := uint64(1); index <= 3; index++ {
+				var shardTimings map[string]time.Duration
+
+				if withTimings {
+					shardTimings = timings
+				}
+
+				shard, err := NewShard(index, 3, shardTimings)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				var counts shardCounts
+
+				for file := range shard.filter(context.Background(), fileChannel(files), &counts, dependsOn) {
+					shards[file.Name] = index
+				}
+			}
+
+			if len(shards) != len(files) {
+				t.Fatalf("expected every file to be assigned once, got %d of %d", len(shards), len(files))
+			}
+
+			for _, chain := range chains {
+				for _, name := range chain[1:] {
+					if shards[name] != shards[chain[0]] {
+						t.Fatalf("expected %v to be in one shard, got %v", chain, shards)
+					}
+				}
+			}
+		})
+	}
+}
@@ -5,10 +5,17 @@ import (
 	"net/url"
 )
 
+// DirectoryConfigFile is the name of the file that declares hooks and params
+// shared by every test under its directory. It is never run as a test.
+const DirectoryConfigFile = "_lab.yaml"
+
 type File struct {
 	Source  Source
 	Name    string
 	Content []byte
+	// Configs are the directory config files of the directories containing
+	// the file within the source, outermost first.
+	Configs []File
 }
 
 func (f File) Resolve(ctx context.Context, u *url.URL) (onNext <-chan File, onError <-chan Error) {
@@ -18,3 +25,19 @@ func (f File) Resolve(ctx context.Context, u *url.URL) (onNext <-chan File, onEr
 
 	return f.Source.Resolve(ctx, u)
 }
+
+// Write creates or replaces a file relative to f. It returns ErrNotWritable
+// when the source of f does not support writing.
+func (f File) Write(ctx context.Context, u *url.URL, content []byte) (string, error) {
+	w, ok := f.Source.(Writer)
+
+	if !ok {
+		return "", ErrNotWritable
+	}
+
+	q := u.Query()
+	q.Set("from", f.Name)
+	u.RawQuery = q.Encode()
+
+	return w.Write(ctx, u, content)
+}
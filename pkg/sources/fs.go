@@ -2,10 +2,12 @@ package sources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gobwas/glob"
 )
@@ -62,7 +64,9 @@ func (fs *FileSystem) Read(ctx context.Context) (<-chan File, <-chan Error) {
 	onError := make(chan Error)
 
 	go func() {
-		fs.traverse(ctx, filepath.Join(fs.dir, fs.name), onNext, onError)
+		configs := fs.parentConfigs(onError)
+
+		fs.traverse(ctx, filepath.Join(fs.dir, fs.name), configs, onNext, onError)
 
 		close(onNext)
 		close(onError)
@@ -81,13 +85,7 @@ func (fs *FileSystem) Resolve(ctx context.Context, u *url.URL) (<-chan File, <-c
 			close(onError)
 		}()
 
-		from := u.Query().Get("from")
-
-		if from == "" {
-			from = fs.dir
-		}
-
-		fp, err := filepath.Abs(filepath.Join(ToDir(from), filepath.Join(u.Host, u.Path)))
+		fp, err := fs.resolvePath(u)
 
 		if err != nil {
 			onError <- NewErrorFrom(u.String(), err)
@@ -95,13 +93,51 @@ func (fs *FileSystem) Resolve(ctx context.Context, u *url.URL) (<-chan File, <-c
 			return
 		}
 
-		fs.traverse(ctx, fp, onNext, onError)
+		// a referenced file is named explicitly, so the discovery filter and
+		// the supported extensions do not apply to it
+		if fi, err := os.Stat(fp); err == nil && fi.Mode().IsRegular() {
+			fs.readFile(fp, nil, onNext, onError)
+
+			return
+		}
+
+		fs.traverse(ctx, fp, nil, onNext, onError)
 	}()
 
 	return onNext, onError
 }
 
-func (fs *FileSystem) traverse(ctx context.Context, path string, onNext chan<- File, onError chan<- Error) {
+// Write creates or replaces the file referenced by the url, resolved like
+// Resolve, and returns its path.
+func (fs *FileSystem) Write(_ context.Context, u *url.URL, content []byte) (string, error) {
+	fp, err := fs.resolvePath(u)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(fp, content, 0o644); err != nil {
+		return "", err
+	}
+
+	return fp, nil
+}
+
+func (fs *FileSystem) resolvePath(u *url.URL) (string, error) {
+	from := u.Query().Get("from")
+
+	if from == "" {
+		from = fs.dir
+	}
+
+	return filepath.Abs(filepath.Join(ToDir(from), filepath.Join(u.Host, u.Path)))
+}
+
+func (fs *FileSystem) traverse(ctx context.Context, path string, configs []File, onNext chan<- File, onError chan<- Error) {
 	fi, err := os.Stat(path)
 
 	if err != nil {
@@ -120,12 +156,16 @@ func (fs *FileSystem) traverse(ctx context.Context, path string, onNext chan<- F
 			return
 		}
 
+		if IsDirectoryConfig(path) {
+			return
+		}
+
 		// if not matched, skip the file
 		if fs.filter != nil && !fs.filter.Match(filename) {
 			return
 		}
 
-		fs.readFile(filename, onNext, onError)
+		fs.readFile(filename, configs, onNext, onError)
 
 		return
 	}
@@ -138,16 +178,18 @@ func (fs *FileSystem) traverse(ctx context.Context, path string, onNext chan<- F
 		return
 	}
 
+	configs = fs.readConfig(path, configs, onError)
+
 	for _, file := range files {
 		filename := filepath.Join(path, file.Name())
 
 		if file.IsDir() {
-			fs.traverse(ctx, filename, onNext, onError)
+			fs.traverse(ctx, filename, configs, onNext, onError)
 
 			continue
 		}
 
-		if !IsSupportedFile(file.Name()) {
+		if !IsSupportedFile(file.Name()) || IsDirectoryConfig(file.Name()) {
 			continue
 		}
 
@@ -156,11 +198,69 @@ func (fs *FileSystem) traverse(ctx context.Context, path string, onNext chan<- F
 			continue
 		}
 
-		fs.readFile(filename, onNext, onError)
+		fs.readFile(filename, configs, onNext, onError)
 	}
 }
 
-func (fs *FileSystem) readFile(filename string, onNext chan<- File, onError chan<- Error) {
+// parentConfigs reads the configs of the directories above the location,
+// from the working directory down, so that a test gets the same configs
+// whether its own directory or a parent directory is run, like the tests of a
+// Git repository get the configs from the repository root down. A single
+// file also gets the config of its own directory.
+func (fs *FileSystem) parentConfigs(onError chan<- Error) []File {
+	dirs := make([]string, 0)
+
+	if wd, err := os.Getwd(); err == nil {
+		rel, err := filepath.Rel(wd, fs.dir)
+
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			for dir := filepath.Dir(fs.dir); ; dir = filepath.Dir(dir) {
+				dirs = append(dirs, dir)
+
+				if dir == wd || dir == filepath.Dir(dir) {
+					break
+				}
+			}
+		}
+	}
+
+	var configs []File
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		configs = fs.readConfig(dirs[i], configs, onError)
+	}
+
+	if fs.name != "" {
+		configs = fs.readConfig(fs.dir, configs, onError)
+	}
+
+	return configs
+}
+
+// readConfig appends the config of the directory, if it has one, to a copy
+// of the configs of its parents.
+func (fs *FileSystem) readConfig(dir string, configs []File, onError chan<- Error) []File {
+	filename := filepath.Join(dir, DirectoryConfigFile)
+	content, err := os.ReadFile(filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return configs
+	}
+
+	if err != nil {
+		onError <- NewErrorFrom(filename, err)
+
+		return configs
+	}
+
+	return append(append([]File{}, configs...), File{
+		Source:  fs,
+		Name:    filename,
+		Content: content,
+	})
+}
+
+func (fs *FileSystem) readFile(filename string, configs []File, onNext chan<- File, onError chan<- Error) {
 	content, err := os.ReadFile(filename)
 
 	if err != nil {
@@ -173,5 +273,6 @@ func (fs *FileSystem) readFile(filename string, onNext chan<- File, onError chan
 		Source:  fs,
 		Name:    filename,
 		Content: content,
+		Configs: configs,
 	}
 }
@@ -253,6 +253,79 @@ func TestFileSystem(t *testing.T) {
 					So(foundFiles, ShouldHaveLength, 3)
 				})
 			})
+
+			Convey("When directories have configs", func() {
+				dir := t.TempDir()
+				nested := filepath.Join(dir, "pages")
+				So(os.MkdirAll(nested, 0o755), ShouldBeNil)
+
+				for name, content := range map[string]string{
+					filepath.Join(dir, sources2.DirectoryConfigFile):    "params: {root: true}",
+					filepath.Join(dir, "root.fql"):                      "RETURN 1",
+					filepath.Join(nested, sources2.DirectoryConfigFile): "params: {pages: true}",
+					filepath.Join(nested, "home.fql"):                   "RETURN 2",
+				} {
+					So(os.WriteFile(name, []byte(content), 0o644), ShouldBeNil)
+				}
+
+				read := func(target string) map[string][]string {
+					src, err := sources2.NewFileSystem(mustParseUrl(target))
+					So(err, ShouldBeNil)
+
+					onNext, onError := src.Read(context.Background())
+					configs := make(map[string][]string)
+
+					for f := range onNext {
+						names := make([]string, 0, len(f.Configs))
+
+						for _, config := range f.Configs {
+							names = append(names, config.Name)
+						}
+
+						configs[f.Name] = names
+					}
+
+					for e := range onError {
+						So(e, ShouldBeNil)
+					}
+
+					return configs
+				}
+
+				Convey("Should attach the configs outermost first and skip them as tests", func() {
+					So(read(dir), ShouldResemble, map[string][]string{
+						filepath.Join(dir, "root.fql"): {
+							filepath.Join(dir, sources2.DirectoryConfigFile),
+						},
+						filepath.Join(nested, "home.fql"): {
+							filepath.Join(dir, sources2.DirectoryConfigFile),
+							filepath.Join(nested, sources2.DirectoryConfigFile),
+						},
+					})
+				})
+
+				Convey("Should attach the config of the directory of a single file", func() {
+					So(read(filepath.Join(nested, "home.fql")), ShouldResemble, map[string][]string{
+						filepath.Join(nested, "home.fql"): {
+							filepath.Join(nested, sources2.DirectoryConfigFile),
+						},
+					})
+				})
+
+				Convey("Should attach the configs of the parents up to the working directory", func() {
+					t.Chdir(dir)
+
+					expected := map[string][]string{
+						filepath.Join(nested, "home.fql"): {
+							filepath.Join(dir, sources2.DirectoryConfigFile),
+							filepath.Join(nested, sources2.DirectoryConfigFile),
+						},
+					}
+
+					So(read(nested), ShouldResemble, expected)
+					So(read(filepath.Join(nested, "home.fql")), ShouldResemble, expected)
+				})
+			})
 		})
 
 		Convey(".Resolve", func() {
@@ -580,3 +653,41 @@ assert:
 		})
 	})
 }
+
+func TestFileSystemWrite(t *testing.T) {
+	Convey("Files system source", t, func() {
+		Convey(".Write", func() {
+			Convey("Should write a file relative to the referencing file and resolve it back", func() {
+				dir := t.TempDir()
+				suite := filepath.Join(dir, "suite.yaml")
+				So(os.WriteFile(suite, []byte("query: {text: RETURN 1}"), 0o644), ShouldBeNil)
+
+				u, _ := url.Parse(suite)
+				src, err := sources2.NewFileSystem(u)
+				So(err, ShouldBeNil)
+
+				file := sources2.File{Source: src, Name: suite}
+
+				written, err := file.Write(context.Background(), mustParseUrl("snapshots/suite.snap.json"), []byte(`{"a":1}`))
+				So(err, ShouldBeNil)
+				So(written, ShouldEqual, filepath.Join(dir, "snapshots", "suite.snap.json"))
+
+				onNext, onError := file.Resolve(context.Background(), mustParseUrl("snapshots/suite.snap.json"))
+
+				select {
+				case e := <-onError:
+					So(e, ShouldBeNil)
+				case f := <-onNext:
+					So(string(f.Content), ShouldEqual, `{"a":1}`)
+				}
+			})
+
+			Convey("Should reject sources that are not writable", func() {
+				file := sources2.File{Source: sources2.NewNoop(), Name: "suite.yaml"}
+
+				_, err := file.Write(context.Background(), mustParseUrl("suite.snap.json"), []byte("{}"))
+				So(err, ShouldEqual, sources2.ErrNotWritable)
+			})
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/url"
+	"path"
 	"path/filepath"
 	"sync"
 
@@ -93,8 +94,10 @@ func (g *Git) Read(ctx context.Context) (<-chan File, <-chan Error) {
 
 		defer files.Close()
 
+		configs := newGitConfigs(g, commit)
+
 		err = files.ForEach(func(f *object.File) error {
-			if !IsSupportedFile(f.Name) {
+			if !IsSupportedFile(f.Name) || IsDirectoryConfig(f.Name) {
 				return nil
 			}
 
@@ -121,10 +124,19 @@ func (g *Git) Read(ctx context.Context) (<-chan File, <-chan Error) {
 				return nil
 			}
 
+			chain, err := configs.chain(f.Name)
+
+			if err != nil {
+				onError <- NewErrorFrom(f.Name, err)
+
+				return nil
+			}
+
 			onNext <- File{
 				Source:  g,
 				Name:    f.Name,
 				Content: content,
+				Configs: chain,
 			}
 
 			return nil
@@ -211,3 +223,81 @@ func (g *Git) getCommit(ctx context.Context) (*object.Commit, error) {
 
 	return g.repo.CommitObject(ref.Hash())
 }
+
+// gitConfigs looks up and caches the directory configs of a commit.
+type gitConfigs struct {
+	source *Git
+	commit *object.Commit
+	files  map[string]*File
+}
+
+func newGitConfigs(source *Git, commit *object.Commit) *gitConfigs {
+	return &gitConfigs{
+		source: source,
+		commit: commit,
+		files:  make(map[string]*File),
+	}
+}
+
+// chain returns the directory configs applying to the file, outermost first.
+func (c *gitConfigs) chain(name string) ([]File, error) {
+	dirs := make([]string, 0)
+
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+
+	chain := make([]File, 0)
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		config, err := c.get(path.Join(dirs[i], DirectoryConfigFile))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if config != nil {
+			chain = append(chain, *config)
+		}
+	}
+
+	return chain, nil
+}
+
+func (c *gitConfigs) get(name string) (*File, error) {
+	if config, found := c.files[name]; found {
+		return config, nil
+	}
+
+	file, err := c.commit.File(name)
+
+	if errors.Is(err, object.ErrFileNotFound) {
+		c.files[name] = nil
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := file.Contents()
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := &File{
+		Source:  c.source,
+		Name:    file.Name,
+		Content: []byte(contents),
+	}
+
+	c.files[name] = config
+
+	return config, nil
+}
@@ -459,5 +459,65 @@ assert:
 				}
 			})
 		})
+
+		Convey("Directory configs", func() {
+			Convey("Should attach the configs of the containing directories and skip them as tests", func() {
+				repo, err := initRepo([]MockFile{
+					{
+						Name: "query-1.fql",
+					},
+				})
+
+				So(err, ShouldBeNil)
+
+				tree, err := repo.Worktree()
+				So(err, ShouldBeNil)
+
+				So(tree.Filesystem.MkdirAll("e2e/pages/", os.ModeDir), ShouldBeNil)
+
+				for name, content := range map[string]string{
+					"_lab.yaml":           "params: {root: true}",
+					"e2e/pages/_lab.yaml": "params: {pages: true}",
+					"e2e/pages/home.fql":  "RETURN TRUE",
+					"e2e/other-query.fql": "RETURN FALSE",
+				} {
+					file, err := tree.Filesystem.Create(name)
+					So(err, ShouldBeNil)
+					_, err = file.Write([]byte(content))
+					So(err, ShouldBeNil)
+					So(file.Close(), ShouldBeNil)
+				}
+
+				So(tree.AddGlob("*"), ShouldBeNil)
+				So(apply(tree), ShouldBeNil)
+
+				src, err := sources.NewGitFrom(repo, nil)
+				So(err, ShouldBeNil)
+
+				onNext, onError := src.Read(context.Background())
+
+				configs := make(map[string][]string)
+
+				for f := range onNext {
+					names := make([]string, 0, len(f.Configs))
+
+					for _, config := range f.Configs {
+						names = append(names, config.Name)
+					}
+
+					configs[f.Name] = names
+				}
+
+				for e := range onError {
+					So(e, ShouldBeNil)
+				}
+
+				So(configs, ShouldResemble, map[string][]string{
+					"query-1.fql":         {"_lab.yaml"},
+					"e2e/other-query.fql": {"_lab.yaml"},
+					"e2e/pages/home.fql":  {"_lab.yaml", "e2e/pages/_lab.yaml"},
+				})
+			})
+		})
 	})
 }
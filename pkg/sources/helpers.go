@@ -13,6 +13,11 @@ func IsSupportedFile(name string) bool {
 	}
 }
 
+// IsDirectoryConfig reports whether the file is a directory config file.
+func IsDirectoryConfig(name string) bool {
+	return filepath.Base(name) == DirectoryConfigFile
+}
+
 func ToDir(path string) string {
 	if filepath.Ext(path) == "" {
 		return path
@@ -0,0 +1,230 @@
+package sources
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+type (
+	// Recorder records the files resolved on behalf of the files of a source,
+	// e.g. the scripts a suite references or the manifests it extends.
+	Recorder struct {
+		mu      sync.Mutex
+		refs    map[string]map[string]bool
+		written map[string]bool
+	}
+
+	// recordedSource resolves files for the owner file and records them.
+	recordedSource struct {
+		Source
+		recorder *Recorder
+		owner    string
+	}
+
+	recorderSource struct {
+		Source
+		recorder *Recorder
+	}
+)
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		refs:    make(map[string]map[string]bool),
+		written: make(map[string]bool),
+	}
+}
+
+// Wrap returns a source whose files, and their directory configs, record
+// the files they resolve.
+func (r *Recorder) Wrap(src Source) Source {
+	return &recorderSource{Source: src, recorder: r}
+}
+
+// Refs returns the names of the files resolved on behalf of the file,
+// directly or through the files it resolved.
+func (r *Recorder) Refs(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refs := make([]string, 0, len(r.refs[name]))
+
+	for ref := range r.refs[name] {
+		refs = append(refs, ref)
+	}
+
+	sort.Strings(refs)
+
+	return refs
+}
+
+// Reset forgets the files resolved on behalf of the file.
+func (r *Recorder) Reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.refs, name)
+}
+
+// Written returns the names of the files written through the source since the
+// previous call, e.g. updated snapshots, and forgets them.
+func (r *Recorder) Written() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	written := make([]string, 0, len(r.written))
+
+	for name := range r.written {
+		written = append(written, name)
+	}
+
+	sort.Strings(written)
+	clear(r.written)
+
+	return written
+}
+
+func (r *Recorder) record(owner string, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refs, found := r.refs[owner]
+
+	if !found {
+		refs = make(map[string]bool)
+		r.refs[owner] = refs
+	}
+
+	refs[name] = true
+}
+
+func (r *Recorder) wrapFile(file File, owner string) File {
+	file.Source = &recordedSource{Source: file.Source, recorder: r, owner: owner}
+
+	if len(file.Configs) > 0 {
+		configs := make([]File, len(file.Configs))
+
+		for i, config := range file.Configs {
+			configs[i] = r.wrapFile(config, config.Name)
+		}
+
+		file.Configs = configs
+	}
+
+	return file
+}
+
+func (s *recorderSource) Read(ctx context.Context) (<-chan File, <-chan Error) {
+	next, onError := s.Source.Read(ctx)
+
+	return s.recorder.forward(ctx, next, onError, "")
+}
+
+func (s *recorderSource) Resolve(ctx context.Context, u *url.URL) (<-chan File, <-chan Error) {
+	next, onError := s.Source.Resolve(ctx, u)
+
+	return s.recorder.forward(ctx, next, onError, "")
+}
+
+func (s *recordedSource) Resolve(ctx context.Context, u *url.URL) (<-chan File, <-chan Error) {
+	next, onError := s.Source.Resolve(ctx, u)
+
+	return s.recorder.forward(ctx, next, onError, s.owner)
+}
+
+// Write keeps the files of writable sources writable and records the
+// written files.
+func (s *recordedSource) Write(ctx context.Context, u *url.URL, content []byte) (string, error) {
+	w, ok := s.Source.(Writer)
+
+	if !ok {
+		return "", ErrNotWritable
+	}
+
+	name, err := w.Write(ctx, u, content)
+
+	if name != "" {
+		s.recorder.mu.Lock()
+		s.recorder.written[name] = true
+		s.recorder.mu.Unlock()
+	}
+
+	return name, err
+}
+
+// forward wraps the files of the channels. Files read from the source are
+// their own owners; resolved files are recorded for the owner they were
+// resolved for and resolve their own references on its behalf. Files and
+// errors are forwarded by one goroutine, so a file sent before the source
+// closed its channels is received before the channels are closed.
+func (r *Recorder) forward(ctx context.Context, next <-chan File, errs <-chan Error, owner string) (<-chan File, <-chan Error) {
+	onNext := make(chan File)
+	onError := make(chan Error)
+
+	go func() {
+		defer func() {
+			close(onNext)
+			close(onError)
+		}()
+
+		for next != nil || errs != nil {
+			select {
+			case file, ok := <-next:
+				if !ok {
+					next = nil
+
+					continue
+				}
+
+				fileOwner := owner
+
+				if fileOwner == "" {
+					fileOwner = file.Name
+				} else {
+					r.record(owner, file.Name)
+				}
+
+				select {
+				case onNext <- r.wrapFile(file, fileOwner):
+				case <-ctx.Done():
+					drain(next, errs)
+
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+
+					continue
+				}
+
+				select {
+				case onError <- err:
+				case <-ctx.Done():
+					drain(next, errs)
+
+					return
+				}
+			}
+		}
+	}()
+
+	return onNext, onError
+}
+
+// drain consumes the channels so that the source can finish.
+func drain(next <-chan File, errs <-chan Error) {
+	for next != nil || errs != nil {
+		select {
+		case _, ok := <-next:
+			if !ok {
+				next = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		}
+	}
+}
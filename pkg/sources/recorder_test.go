@@ -0,0 +1,104 @@
+package sources_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	sources2 "github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("Recorder", t, func() {
+		dir := t.TempDir()
+		So(os.MkdirAll(filepath.Join(dir, "tests"), 0o755), ShouldBeNil)
+		So(os.MkdirAll(filepath.Join(dir, "shared"), 0o755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "tests", sources2.DirectoryConfigFile), []byte("beforeAll: {ref: ../shared/setup.fql}"), 0o644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "tests", "suite.yaml"), []byte("query: {ref: ../shared/base.yaml}"), 0o644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "shared", "base.yaml"), []byte("query: {text: RETURN 1}"), 0o644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "shared", "query.fql"), []byte("RETURN 1"), 0o644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "shared", "setup.fql"), []byte("RETURN 1"), 0o644), ShouldBeNil)
+
+		u, _ := url.Parse(filepath.Join(dir, "tests"))
+		src, err := sources2.NewFileSystem(u)
+		So(err, ShouldBeNil)
+
+		recorder := sources2.NewRecorder()
+
+		onNext, onError := recorder.Wrap(src).Read(context.Background())
+
+		files := make([]sources2.File, 0)
+
+		for onNext != nil || onError != nil {
+			select {
+			case f, ok := <-onNext:
+				if !ok {
+					onNext = nil
+
+					continue
+				}
+
+				files = append(files, f)
+			case e, ok := <-onError:
+				if !ok {
+					onError = nil
+
+					continue
+				}
+
+				So(e, ShouldBeNil)
+			}
+		}
+
+		So(files, ShouldHaveLength, 1)
+
+		suite := files[0]
+		So(suite.Configs, ShouldHaveLength, 1)
+
+		resolve := func(file sources2.File, ref string) sources2.File {
+			onNext, onError := file.Resolve(context.Background(), mustParseUrl(ref))
+
+			select {
+			case e := <-onError:
+				So(e, ShouldBeNil)
+			case f := <-onNext:
+				return f
+			}
+
+			return sources2.File{}
+		}
+
+		Convey("Should record the files resolved for a file and the files they resolve", func() {
+			base := resolve(suite, "../shared/base.yaml")
+			resolve(base, "query.fql")
+
+			So(recorder.Refs(suite.Name), ShouldResemble, []string{
+				filepath.Join(dir, "shared", "base.yaml"),
+				filepath.Join(dir, "shared", "query.fql"),
+			})
+
+			recorder.Reset(suite.Name)
+			So(recorder.Refs(suite.Name), ShouldBeEmpty)
+		})
+
+		Convey("Should record the files resolved for a directory config under the config", func() {
+			resolve(suite.Configs[0], "../shared/setup.fql")
+
+			So(recorder.Refs(suite.Configs[0].Name), ShouldResemble, []string{filepath.Join(dir, "shared", "setup.fql")})
+			So(recorder.Refs(suite.Name), ShouldBeEmpty)
+		})
+
+		Convey("Should keep the files writable", func() {
+			written, err := suite.Write(context.Background(), mustParseUrl("suite.snap.json"), []byte("{}"))
+
+			So(err, ShouldBeNil)
+			So(written, ShouldEqual, filepath.Join(dir, "tests", "suite.snap.json"))
+			So(recorder.Written(), ShouldResemble, []string{written})
+			So(recorder.Written(), ShouldBeEmpty)
+		})
+	})
+}
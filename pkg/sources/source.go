@@ -13,6 +13,12 @@ type (
 		Resolve(ctx context.Context, url *url.URL) (onNext <-chan File, onError <-chan Error)
 	}
 
+	// Writer is implemented by sources whose files can be created and
+	// replaced in place, e.g. to update snapshots.
+	Writer interface {
+		Write(ctx context.Context, url *url.URL, content []byte) (string, error)
+	}
+
 	SourceFactory func(u *url.URL) (Source, error)
 
 	SourceType int
@@ -25,6 +31,9 @@ const (
 	SourceTypeGIT     SourceType = 3
 )
 
+// ErrNotWritable is returned when writing to a source that does not implement Writer.
+var ErrNotWritable = errors.New("source does not support writing")
+
 var typeByScheme = map[string]SourceType{
 	"file":      SourceTypeFS,
 	"http":      SourceTypeHTTP,
@@ -14,6 +14,9 @@ type (
 	Options struct {
 		File    sources.File
 		Timeout time.Duration
+		// UpdateSnapshots rewrites snapshots with the current output instead
+		// of comparing against them.
+		UpdateSnapshots bool
 	}
 
 	Case interface {
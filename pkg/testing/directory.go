@@ -0,0 +1,133 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+type (
+	// DirectoryManifest is the content of a directory config file ("_lab.yaml").
+	DirectoryManifest struct {
+		// BeforeAll runs once before the first test under the directory; its
+		// output is exposed to those tests as @lab.data.beforeAll.result.
+		BeforeAll *ScriptManifest `yaml:"beforeAll"`
+		// AfterAll runs once after every test under the directory finished.
+		AfterAll *ScriptManifest `yaml:"afterAll"`
+		// Params are shared by every test under the directory.
+		Params map[string]any `yaml:"params"`
+	}
+
+	// Directory applies the hooks and params of a directory config to the
+	// tests under its directory.
+	Directory struct {
+		file     sources.File
+		timeout  time.Duration
+		manifest DirectoryManifest
+	}
+)
+
+func NewDirectory(opts Options) (*Directory, error) {
+	manifest := DirectoryManifest{}
+
+	if err := yaml.Unmarshal(opts.File.Content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Directory{
+		file:     opts.File,
+		timeout:  opts.Timeout,
+		manifest: manifest,
+	}, nil
+}
+
+// Name returns the name of the directory config file.
+func (dir *Directory) Name() string {
+	return dir.file.Name
+}
+
+// Params returns the params shared by the tests under the directory.
+func (dir *Directory) Params() map[string]any {
+	return dir.manifest.Params
+}
+
+// BeforeAll runs the beforeAll script, if any, and stores its output in the
+// params as @lab.data.beforeAll.
+func (dir *Directory) BeforeAll(ctx context.Context, rt runtime.Runtime, params Params) error {
+	if dir.manifest.BeforeAll == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dir.timeout)
+	defer cancel()
+
+	script, err := resolveScript(ctx, dir.file, "beforeAll", *dir.manifest.BeforeAll)
+	if err != nil {
+		return fmt.Errorf("resolve beforeAll script: %w", err)
+	}
+
+	scriptParams := dir.manifest.BeforeAll.runtimeParams(params.Clone())
+
+	out, err := rt.Run(ctx, script, scriptParams)
+	if err != nil {
+		return fmt.Errorf("failed to execute beforeAll script: %w", err)
+	}
+
+	outVal, err := deserializeOutput(out)
+	if err != nil {
+		return fmt.Errorf("deserialize beforeAll output: %w", err)
+	}
+
+	params.SetDataValue("beforeAll", DataContextValues{
+		Result: outVal,
+		Params: scriptParams,
+	})
+
+	return nil
+}
+
+// AfterAll runs the afterAll script, if any.
+func (dir *Directory) AfterAll(ctx context.Context, rt runtime.Runtime, params Params) error {
+	if dir.manifest.AfterAll == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dir.timeout)
+	defer cancel()
+
+	script, err := resolveScript(ctx, dir.file, "afterAll", *dir.manifest.AfterAll)
+	if err != nil {
+		return fmt.Errorf("resolve afterAll script: %w", err)
+	}
+
+	if _, err := rt.Run(ctx, script, dir.manifest.AfterAll.runtimeParams(params.Clone())); err != nil {
+		return fmt.Errorf("failed to execute afterAll script: %w", err)
+	}
+
+	return nil
+}
+
+func (manifest DirectoryManifest) validate() error {
+	if manifest.BeforeAll != nil {
+		if err := manifest.BeforeAll.validate(); err != nil {
+			return fmt.Errorf("beforeAll: %w", err)
+		}
+	}
+
+	if manifest.AfterAll != nil {
+		if err := manifest.AfterAll.validate(); err != nil {
+			return fmt.Errorf("afterAll: %w", err)
+		}
+	}
+
+	return nil
+}
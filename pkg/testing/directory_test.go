@@ -0,0 +1,77 @@
+package testing_test
+
+import (
+	"context"
+	"reflect"
+	stdtesting "testing"
+	"time"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestDirectoryBeforeAllExposesResult(t *stdtesting.T) {
+	dir, err := testing2.NewDirectory(testing2.Options{
+		File: sources.File{
+			Name: "e2e/_lab.yaml",
+			Content: []byte(`
+beforeAll:
+  text: RETURN { token }
+  params:
+    user: admin
+params:
+  baseUrl: http://localhost
+`),
+		},
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(dir.Params(), map[string]any{"baseUrl": "http://localhost"}) {
+		t.Fatalf("unexpected directory params: %v", dir.Params())
+	}
+
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, params map[string]any) ([]byte, error) {
+		if params["user"] != "admin" {
+			t.Fatalf("expected beforeAll params, got %v", params)
+		}
+
+		return []byte(`{"token":"abc"}`), nil
+	})
+
+	params := testing2.NewParams()
+
+	if err := dir.BeforeAll(context.Background(), rt, params); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data := params.ToMap()["lab"].(map[string]any)["data"].(map[string]any)
+	beforeAll := data["beforeAll"].(map[string]any)
+
+	if !reflect.DeepEqual(beforeAll["result"], map[string]any{"token": "abc"}) {
+		t.Fatalf("unexpected beforeAll result: %v", beforeAll["result"])
+	}
+}
+
+func TestDirectoryValidation(t *stdtesting.T) {
+	for field, expected := range map[string]string{
+		"beforeAll": "beforeAll: ref or text must have value",
+		"afterAll":  "afterAll: ref or text must have value",
+	} {
+		_, err := testing2.NewDirectory(testing2.Options{
+			File: sources.File{
+				Name:    "_lab.yaml",
+				Content: []byte(field + ": {}\n"),
+			},
+		})
+
+		if err == nil || err.Error() != expected {
+			t.Fatalf("expected %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ErrorKindCompile marks errors reported while Ferret compiles the query.
+	ErrorKindCompile = "compile"
+	// ErrorKindRuntime marks errors reported while the query executes.
+	ErrorKindRuntime = "runtime"
+)
+
+var (
+	// compileErrorPattern matches the wording of Ferret's compiler and of the
+	// ANTLR parser it is generated with. Parse errors of values, such as JSON
+	// or dates parsed while the query runs, are runtime errors.
+	compileErrorPattern = regexp.MustCompile(`(?i)\bsyntax\s*error\b|\bcompilation\s*error\b|\bfailed to compile\b|\b(mismatched|extraneous) input\b|\bno viable alternative\b`)
+	linePattern         = regexp.MustCompile(`(?i)\bline\s+(\d+)(?::(\d+)|\s*,?\s*col(?:umn)?\s+(\d+))?`)
+	positionPattern     = regexp.MustCompile(`(?:^|[\s(@:])(\d+):(\d+)\b`)
+)
+
+type (
+	// ErrorExpectationManifest describes the error a query is expected to
+	// fail with. Every declared field must hold; an empty manifest accepts
+	// any error.
+	ErrorExpectationManifest struct {
+		Contains    string `yaml:"contains,omitempty"`
+		NotContains string `yaml:"not_contains,omitempty"`
+		Equals      string `yaml:"equals,omitempty"`
+		Matches     string `yaml:"matches,omitempty"`
+		// Kind is either "compile" or "runtime".
+		Kind   string `yaml:"kind,omitempty"`
+		Line   int    `yaml:"line,omitempty"`
+		Column int    `yaml:"column,omitempty"`
+
+		pattern *regexp.Regexp
+	}
+
+	// queryError is what Lab can tell about a query error from its message.
+	// The binary and HTTP runtimes only return Ferret's error text, so the
+	// kind and position are read from the message for every runtime.
+	queryError struct {
+		kind   string
+		line   int
+		column int
+	}
+)
+
+// UnmarshalYAML rejects unknown nested fields without enabling strict decoding
+// for the rest of the suite manifest.
+func (manifest *ErrorExpectationManifest) UnmarshalYAML(unmarshal func(any) error) error {
+	decoded := struct {
+		Contains    string         `yaml:"contains,omitempty"`
+		NotContains string         `yaml:"not_contains,omitempty"`
+		Equals      string         `yaml:"equals,omitempty"`
+		Matches     string         `yaml:"matches,omitempty"`
+		Kind        string         `yaml:"kind,omitempty"`
+		Line        int            `yaml:"line,omitempty"`
+		Column      int            `yaml:"column,omitempty"`
+		Unknown     map[string]any `yaml:",inline"`
+	}{}
+
+	if err := unmarshal(&decoded); err != nil {
+		return err
+	}
+
+	if len(decoded.Unknown) > 0 {
+		fields := make([]string, 0, len(decoded.Unknown))
+
+		for field := range decoded.Unknown {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+
+		if len(fields) == 1 {
+			return fmt.Errorf("expect.error contains unsupported field %q", fields[0])
+		}
+
+		quotedFields := make([]string, len(fields))
+
+		for i, field := range fields {
+			quotedFields[i] = fmt.Sprintf("%q", field)
+		}
+
+		return fmt.Errorf("expect.error contains unsupported fields %s", strings.Join(quotedFields, ", "))
+	}
+
+	switch decoded.Kind {
+	case "", ErrorKindCompile, ErrorKindRuntime:
+	default:
+		return fmt.Errorf("expect.error: kind must be %q or %q, got %q", ErrorKindCompile, ErrorKindRuntime, decoded.Kind)
+	}
+
+	if decoded.Line < 0 || decoded.Column < 0 {
+		return errors.New("expect.error: line and column must be positive")
+	}
+
+	if decoded.Column > 0 && decoded.Line == 0 {
+		return errors.New("expect.error: column requires line")
+	}
+
+	*manifest = ErrorExpectationManifest{
+		Contains:    decoded.Contains,
+		NotContains: decoded.NotContains,
+		Equals:      decoded.Equals,
+		Matches:     decoded.Matches,
+		Kind:        decoded.Kind,
+		Line:        decoded.Line,
+		Column:      decoded.Column,
+	}
+
+	if decoded.Matches != "" {
+		pattern, err := regexp.Compile(decoded.Matches)
+		if err != nil {
+			return fmt.Errorf("expect.error: invalid matches pattern: %w", err)
+		}
+
+		manifest.pattern = pattern
+	}
+
+	return nil
+}
+
+func (manifest ErrorExpectationManifest) evaluate(actual error) error {
+	if actual == nil {
+		return errors.New("expected query to fail, but it completed successfully")
+	}
+
+	message := actual.Error()
+	described := describeQueryError(message)
+
+	if manifest.Kind != "" && described.kind != manifest.Kind {
+		return fmt.Errorf("expected a %s error, got a %s error: %v", manifest.Kind, described.kind, actual)
+	}
+
+	if manifest.Line > 0 {
+		if described.line == 0 {
+			return fmt.Errorf("expected error at line %d, but the error reports no position: %v", manifest.Line, actual)
+		}
+
+		if described.line != manifest.Line {
+			return fmt.Errorf("expected error at line %d, got line %d: %v", manifest.Line, described.line, actual)
+		}
+	}
+
+	if manifest.Column > 0 && described.column != manifest.Column {
+		return fmt.Errorf("expected error at column %d, got column %d: %v", manifest.Column, described.column, actual)
+	}
+
+	if manifest.Equals != "" && strings.TrimSpace(message) != strings.TrimSpace(manifest.Equals) {
+		return fmt.Errorf("expected error equal to %q, got: %v", manifest.Equals, actual)
+	}
+
+	if manifest.Contains != "" && !strings.Contains(message, manifest.Contains) {
+		return fmt.Errorf("expected error containing %q, got: %v", manifest.Contains, actual)
+	}
+
+	if manifest.NotContains != "" && strings.Contains(message, manifest.NotContains) {
+		return fmt.Errorf("expected error not containing %q, got: %v", manifest.NotContains, actual)
+	}
+
+	if manifest.pattern != nil && !manifest.pattern.MatchString(message) {
+		return fmt.Errorf("expected error matching %q, got: %v", manifest.Matches, actual)
+	}
+
+	return nil
+}
+
+// describeQueryError classifies an error message as a compile error when it
+// names a syntax or compilation error or reports a parser mismatch, and
+// reads the first position reported as "line 3, column 5", "line 3:5" or
+// "3:5".
+func describeQueryError(message string) queryError {
+	described := queryError{kind: ErrorKindRuntime}
+
+	if compileErrorPattern.MatchString(message) {
+		described.kind = ErrorKindCompile
+	}
+
+	if match := linePattern.FindStringSubmatch(message); match != nil {
+		described.line, _ = strconv.Atoi(match[1])
+		described.column, _ = strconv.Atoi(match[2] + match[3])
+
+		return described
+	}
+
+	if match := positionPattern.FindStringSubmatch(message); match != nil {
+		described.line, _ = strconv.Atoi(match[1])
+		described.column, _ = strconv.Atoi(match[2])
+	}
+
+	return described
+}
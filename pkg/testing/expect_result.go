@@ -0,0 +1,399 @@
+package testing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxFormattedValueLength = 200
+
+// ResultExpectationManifest is a declarative assertion on the query result.
+// Path selects the values to check; every declared operator must hold for
+// every selected value.
+type ResultExpectationManifest struct {
+	Path     string
+	Equals   any
+	Contains any
+	Matches  string
+	Length   *int
+	Type     string
+	GT       *float64
+	GTE      *float64
+	LT       *float64
+	LTE      *float64
+	Exists   *bool
+
+	hasEquals   bool
+	hasContains bool
+	selector    Selector
+	pattern     *regexp.Regexp
+}
+
+var resultTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+	"null":    true,
+}
+
+// UnmarshalYAML compiles the selector and pattern and rejects unknown fields
+// so that typos do not silently disable an assertion.
+func (manifest *ResultExpectationManifest) UnmarshalYAML(unmarshal func(any) error) error {
+	fields := map[string]any{}
+
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+
+	decoded := ResultExpectationManifest{}
+	unknown := make([]string, 0)
+
+	for field, value := range fields {
+		var err error
+
+		switch field {
+		case "path":
+			decoded.Path, err = stringField(field, value)
+		case "equals":
+			decoded.Equals = normalizeValue(value)
+			decoded.hasEquals = true
+		case "contains":
+			decoded.Contains = normalizeValue(value)
+			decoded.hasContains = true
+		case "matches":
+			decoded.Matches, err = stringField(field, value)
+		case "length":
+			length, ok := value.(int)
+
+			if !ok || length < 0 {
+				err = errors.New("length must be a non-negative integer")
+			}
+
+			decoded.Length = &length
+		case "type":
+			decoded.Type, err = stringField(field, value)
+
+			if err == nil && !resultTypes[decoded.Type] {
+				err = fmt.Errorf("unsupported type %q", decoded.Type)
+			}
+		case "gt":
+			decoded.GT, err = numberField(field, value)
+		case "gte":
+			decoded.GTE, err = numberField(field, value)
+		case "lt":
+			decoded.LT, err = numberField(field, value)
+		case "lte":
+			decoded.LTE, err = numberField(field, value)
+		case "exists":
+			exists, ok := value.(bool)
+
+			if !ok {
+				err = errors.New("exists must be a boolean")
+			}
+
+			decoded.Exists = &exists
+		default:
+			unknown = append(unknown, field)
+		}
+
+		if err != nil {
+			return fmt.Errorf("expect.result: %w", err)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+
+		return fmt.Errorf("expect.result contains unsupported field %q", unknown[0])
+	}
+
+	if decoded.Path == "" {
+		return errors.New("expect.result: path must have value")
+	}
+
+	selector, err := ParseSelector(decoded.Path)
+	if err != nil {
+		return fmt.Errorf("expect.result: %w", err)
+	}
+
+	decoded.selector = selector
+
+	if decoded.Matches != "" {
+		pattern, err := regexp.Compile(decoded.Matches)
+		if err != nil {
+			return fmt.Errorf("expect.result: invalid matches pattern: %w", err)
+		}
+
+		decoded.pattern = pattern
+	}
+
+	if !decoded.hasOperator() {
+		return fmt.Errorf("expect.result: %s must declare at least one operator", decoded.Path)
+	}
+
+	*manifest = decoded
+
+	return nil
+}
+
+func (manifest ResultExpectationManifest) hasOperator() bool {
+	return manifest.hasEquals || manifest.hasContains || manifest.pattern != nil || manifest.Length != nil ||
+		manifest.Type != "" || manifest.GT != nil || manifest.GTE != nil || manifest.LT != nil ||
+		manifest.LTE != nil || manifest.Exists != nil
+}
+
+// evaluateResultExpectations checks every expectation and reports all
+// violations at once.
+func evaluateResultExpectations(expectations []ResultExpectationManifest, result any) error {
+	errs := make([]error, 0)
+
+	for _, expectation := range expectations {
+		errs = append(errs, expectation.evaluate(result)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (manifest ResultExpectationManifest) evaluate(result any) []error {
+	selected := manifest.selector.Select(result)
+
+	if manifest.Exists != nil {
+		switch {
+		case *manifest.Exists && len(selected) == 0:
+			return []error{fmt.Errorf("%s: expected to exist, but it was not found", manifest.selector)}
+		case !*manifest.Exists && len(selected) > 0:
+			return []error{fmt.Errorf("%s: expected not to exist, got %s", selected[0].Path, formatValue(selected[0].Value))}
+		case !*manifest.Exists:
+			return nil
+		}
+	}
+
+	if len(selected) == 0 {
+		return []error{fmt.Errorf("%s: path not found", manifest.selector)}
+	}
+
+	errs := make([]error, 0)
+
+	for _, value := range selected {
+		for _, message := range manifest.check(value.Value) {
+			errs = append(errs, fmt.Errorf("%s: %s", value.Path, message))
+		}
+	}
+
+	return errs
+}
+
+func (manifest ResultExpectationManifest) check(value any) []string {
+	messages := make([]string, 0)
+	fail := func(format string, args ...any) {
+		messages = append(messages, fmt.Sprintf(format, args...))
+	}
+
+	if manifest.hasEquals && !reflect.DeepEqual(value, manifest.Equals) {
+		fail("expected %s, got %s", formatValue(manifest.Equals), formatValue(value))
+	}
+
+	if manifest.hasContains && !containsValue(value, manifest.Contains) {
+		fail("expected to contain %s, got %s", formatValue(manifest.Contains), formatValue(value))
+	}
+
+	if manifest.pattern != nil {
+		text, ok := value.(string)
+
+		if !ok || !manifest.pattern.MatchString(text) {
+			fail("expected to match %q, got %s", manifest.Matches, formatValue(value))
+		}
+	}
+
+	if manifest.Length != nil {
+		length, ok := valueLength(value)
+
+		switch {
+		case !ok:
+			fail("expected length %d, got %s without a length", *manifest.Length, typeOf(value))
+		case length != *manifest.Length:
+			fail("expected length %d, got length %d: %s", *manifest.Length, length, formatValue(value))
+		}
+	}
+
+	if manifest.Type != "" && typeOf(value) != manifest.Type {
+		fail("expected type %s, got %s %s", manifest.Type, typeOf(value), formatValue(value))
+	}
+
+	comparisons := []struct {
+		name     string
+		expected *float64
+		holds    func(actual float64, expected float64) bool
+	}{
+		{"greater than", manifest.GT, func(a, e float64) bool { return a > e }},
+		{"greater than or equal to", manifest.GTE, func(a, e float64) bool { return a >= e }},
+		{"less than", manifest.LT, func(a, e float64) bool { return a < e }},
+		{"less than or equal to", manifest.LTE, func(a, e float64) bool { return a <= e }},
+	}
+
+	for _, comparison := range comparisons {
+		if comparison.expected == nil {
+			continue
+		}
+
+		number, ok := value.(float64)
+
+		if !ok || !comparison.holds(number, *comparison.expected) {
+			fail("expected %s %v, got %s", comparison.name, *comparison.expected, formatValue(value))
+		}
+	}
+
+	return messages
+}
+
+func containsValue(value any, expected any) bool {
+	switch v := value.(type) {
+	case string:
+		text, ok := expected.(string)
+
+		return ok && strings.Contains(v, text)
+	case []any:
+		for _, item := range v {
+			if reflect.DeepEqual(item, expected) {
+				return true
+			}
+		}
+
+		return false
+	case map[string]any:
+		key, ok := expected.(string)
+		if !ok {
+			return false
+		}
+
+		_, exists := v[key]
+
+		return exists
+	default:
+		return false
+	}
+}
+
+func valueLength(value any) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v), true
+	case []any:
+		return len(v), true
+	case map[string]any:
+		return len(v), true
+	default:
+		return 0, false
+	}
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func formatValue(value any) string {
+	data, err := json.Marshal(value)
+
+	text := string(data)
+
+	if err != nil {
+		text = fmt.Sprint(value)
+	}
+
+	if len(text) > maxFormattedValueLength {
+		cut := maxFormattedValueLength
+
+		// do not split a multi-byte character
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+
+		text = text[:cut] + "…"
+	}
+
+	return text
+}
+
+// normalizeValue converts YAML values into the shapes produced by decoding
+// JSON so that expected and actual values compare equal.
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(v))
+
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalizeValue(item)
+		}
+
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for key, item := range v {
+			out[key] = normalizeValue(item)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			out[i] = normalizeValue(item)
+		}
+
+		return out
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return value
+	}
+}
+
+func stringField(name string, value any) (string, error) {
+	text, ok := value.(string)
+
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+
+	return text, nil
+}
+
+func numberField(name string, value any) (*float64, error) {
+	number, ok := normalizeValue(value).(float64)
+
+	if !ok {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+
+	return &number, nil
+}
@@ -0,0 +1,196 @@
+package testing_test
+
+import (
+	"context"
+	"strings"
+	stdtesting "testing"
+	"unicode/utf8"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+const expectResultOutput = `{
+	"title": "Example Domain",
+	"price": 12.5,
+	"items": [{"name": "a"}, {"name": "b"}],
+	"tags": ["news", "tech"],
+	"author": null
+}`
+
+func TestSuiteExpectResult(t *stdtesting.T) {
+	tests := []struct {
+		name     string
+		expect   string
+		expected []string
+	}{
+		{
+			name: "passing operators",
+			expect: `
+    - path: $.title
+      equals: Example Domain
+      matches: "^Example"
+      type: string
+      length: 14
+    - path: $.price
+      gt: 10
+      lte: 12.5
+      type: number
+    - path: $.items
+      length: 2
+      equals: [{name: a}, {name: b}]
+    - path: $.items[*].name
+      type: string
+    - path: $.tags
+      contains: tech
+    - path: $.author
+      exists: true
+      type: "null"
+    - path: $.missing
+      exists: false
+`,
+		},
+		{
+			name: "failures report path and actual value",
+			expect: `
+    - path: $.title
+      equals: Other
+    - path: $.items[*].name
+      equals: a
+    - path: $.price
+      lt: 10
+    - path: $.tags
+      length: 3
+    - path: $.missing
+      type: string
+    - path: $.title
+      exists: false
+`,
+			expected: []string{
+				`$.title: expected "Other", got "Example Domain"`,
+				`$.items[1].name: expected "a", got "b"`,
+				`$.price: expected less than 10, got 12.5`,
+				`$.tags: expected length 3, got length 2: ["news","tech"]`,
+				`$.missing: path not found`,
+				`$.title: expected not to exist, got "Example Domain"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			testCase, err := testing2.New(testing2.Options{
+				File: sources.File{
+					Name:    "suite.yaml",
+					Content: []byte("query:\n  text: RETURN 1\nexpect:\n  result:" + test.expect),
+				},
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			var calls int
+
+			rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+				calls++
+
+				return []byte(expectResultOutput), nil
+			})
+
+			err = testCase.Run(context.Background(), rt, testing2.NewParams())
+
+			if calls != 1 {
+				t.Fatalf("expected only the query to run, got %d runtime calls", calls)
+			}
+
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			lines := strings.Split(strings.TrimPrefix(err.Error(), "expect.result: "), "\n")
+
+			if strings.Join(lines, "\n") != strings.Join(test.expected, "\n") {
+				t.Fatalf("unexpected failures:\n%s\nexpected:\n%s", strings.Join(lines, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestSuiteExpectResultValidation(t *stdtesting.T) {
+	tests := map[string]string{
+		"    - equals: 1":                       "expect.result: path must have value",
+		"    - path: $.a":                       "expect.result: $.a must declare at least one operator",
+		"    - path: a\n      equals: 1":        `expect.result: selector "a" must start with $`,
+		"    - path: $.a\n      matches: \"(\"": "expect.result: invalid matches pattern",
+		"    - path: $.a\n      type: integer":  `expect.result: unsupported type "integer"`,
+		"    - path: $.a\n      length: -1":     "expect.result: length must be a non-negative integer",
+		"    - path: $.a\n      gt: many":       "expect.result: gt must be a number",
+		"    - path: $.a\n      equal: 1":       `expect.result contains unsupported field "equal"`,
+	}
+
+	for expect, expected := range tests {
+		_, err := testing2.New(testing2.Options{
+			File: sources.File{
+				Name:    "suite.yaml",
+				Content: []byte("query:\n  text: RETURN 1\nexpect:\n  result:\n" + expect + "\n"),
+			},
+		})
+
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%q: expected error containing %q, got %v", expect, expected, err)
+		}
+	}
+
+	_, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name:    "suite.yaml",
+			Content: []byte("query:\n  text: RETURN 1\nexpect:\n  error: {}\n  result:\n    - path: $\n      exists: true\n"),
+		},
+	})
+
+	if err == nil || err.Error() != "expect.error cannot be combined with expect.result" {
+		t.Fatalf("expected combination error, got %v", err)
+	}
+}
+
+func TestSuiteExpectResultTruncatesOnCharacterBoundary(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name:    "suite.yaml",
+			Content: []byte("query:\n  text: RETURN 1\nexpect:\n  result:\n    - path: $.title\n      equals: Other\n"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		return []byte(`{"title": "` + strings.Repeat("é", 150) + `"}`), nil
+	})
+
+	err = testCase.Run(context.Background(), rt, testing2.NewParams())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	message := err.Error()
+
+	if !utf8.ValidString(message) {
+		t.Fatalf("expected valid UTF-8, got %q", message)
+	}
+
+	if expected := `got "` + strings.Repeat("é", 99) + "…"; !strings.HasSuffix(message, expected) {
+		t.Fatalf("expected the value to be truncated to whole characters, got %q", message)
+	}
+}
@@ -0,0 +1,244 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+// scriptFields are the manifest fields holding scripts whose refs are
+// resolved relative to the file declaring them.
+var scriptFields = []string{"query", "assert", "setup", "teardown"}
+
+// decodeManifest decodes a suite file. When the file declares 'extends', the
+// base manifest is resolved relative to the file, decoded the same way and
+// deep-merged under the file's own manifest, see mergeManifests.
+func decodeManifest(file sources.File) (SuiteManifest, error) {
+	manifest := SuiteManifest{}
+
+	raw := make(map[any]any)
+
+	if err := yaml.Unmarshal(file.Content, &raw); err != nil {
+		return manifest, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	if _, found := raw["extends"]; !found {
+		if err := yaml.Unmarshal(file.Content, &manifest); err != nil {
+			return manifest, fmt.Errorf("failed to parse file: %w", err)
+		}
+
+		return manifest, nil
+	}
+
+	merged, err := extendManifest(context.Background(), file, raw, []string{file.Name})
+	if err != nil {
+		return manifest, err
+	}
+
+	content, err := yaml.Marshal(merged)
+	if err != nil {
+		return manifest, fmt.Errorf("extends: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// extendManifest resolves the chain of base manifests of the raw manifest.
+// The chain holds the names of the files visited so far and is reported in
+// errors.
+func extendManifest(ctx context.Context, file sources.File, raw map[any]any, chain []string) (map[any]any, error) {
+	value, found := raw["extends"]
+	if !found {
+		return raw, nil
+	}
+
+	delete(raw, "extends")
+
+	ref, ok := value.(string)
+	if !ok || strings.TrimSpace(ref) == "" {
+		return nil, fmt.Errorf("extends %s: must be a file reference", strings.Join(chain, " -> "))
+	}
+
+	base, err := resolveRef(ctx, file, ref)
+	if err != nil {
+		return nil, fmt.Errorf("extends %s: %w", strings.Join(chain, " -> "), err)
+	}
+
+	chain = append(chain, base.Name)
+
+	for _, name := range chain[:len(chain)-1] {
+		if name == base.Name {
+			return nil, fmt.Errorf("extends %s: cycle detected", strings.Join(chain, " -> "))
+		}
+	}
+
+	baseRaw := make(map[any]any)
+
+	if err := yaml.Unmarshal(base.Content, &baseRaw); err != nil {
+		return nil, fmt.Errorf("extends %s: failed to parse file: %w", strings.Join(chain, " -> "), err)
+	}
+
+	baseRaw, err = extendManifest(ctx, base, baseRaw, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	rebaseManifestRefs(baseRaw, base.Name, file.Name)
+
+	return mergeManifests(baseRaw, raw), nil
+}
+
+// mergeManifests deep-merges the override manifest into the base one. The
+// source of a script is atomic: a script declaring 'text' or 'ref' replaces
+// both of the base script, while the script params are still merged. Like
+// for cases, 'assert' and 'expect' are replaced together so that a suite can
+// switch between asserting a result and expecting an error.
+func mergeManifests(base map[any]any, override map[any]any) map[any]any {
+	merged := make(map[any]any, len(base)+len(override))
+
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	if _, found := override["assert"]; found {
+		delete(merged, "expect")
+	}
+
+	if _, found := override["expect"]; found {
+		delete(merged, "assert")
+		delete(merged, "expect")
+	}
+
+	for _, field := range scriptFields {
+		current, ok := merged[field].(map[any]any)
+		if !ok {
+			continue
+		}
+
+		script, ok := override[field].(map[any]any)
+		if !ok || !declaresSource(script) {
+			continue
+		}
+
+		rest := make(map[any]any, len(current))
+
+		for key, value := range current {
+			if key != "text" && key != "ref" {
+				rest[key] = value
+			}
+		}
+
+		merged[field] = rest
+	}
+
+	for key, value := range override {
+		if current, found := merged[key]; found {
+			merged[key] = mergeValues(current, value)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// mergeValues deep-merges override into base: maps are merged key by key,
+// any other value of override replaces the base value.
+func mergeValues(base any, override any) any {
+	baseMap, ok := base.(map[any]any)
+	if !ok {
+		return override
+	}
+
+	overrideMap, ok := override.(map[any]any)
+	if !ok {
+		return override
+	}
+
+	merged := make(map[any]any, len(baseMap)+len(overrideMap))
+
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+
+	for key, value := range overrideMap {
+		if current, found := merged[key]; found {
+			merged[key] = mergeValues(current, value)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// declaresSource reports whether the script declares its text or ref.
+func declaresSource(script map[any]any) bool {
+	_, text := script["text"]
+	_, ref := script["ref"]
+
+	return text || ref
+}
+
+// rebaseManifestRefs rewrites the script and schema refs of a base manifest
+// so that they keep pointing to the same files once the manifest is merged
+// into a manifest declared in another file.
+func rebaseManifestRefs(raw map[any]any, from string, to string) {
+	rebaseScriptRefs(raw, from, to)
+
+	if cases, ok := raw["cases"].([]any); ok {
+		for _, c := range cases {
+			if m, ok := c.(map[any]any); ok {
+				rebaseScriptRefs(m, from, to)
+			}
+		}
+	}
+}
+
+func rebaseScriptRefs(raw map[any]any, from string, to string) {
+	for _, field := range scriptFields {
+		if script, ok := raw[field].(map[any]any); ok {
+			rebaseRef(script, from, to)
+		}
+	}
+
+	expect, ok := raw["expect"].(map[any]any)
+	if !ok {
+		return
+	}
+
+	// only a schema reference has 'ref' as its single key
+	if schema, ok := expect["schema"].(map[any]any); ok && len(schema) == 1 {
+		rebaseRef(schema, from, to)
+	}
+}
+
+func rebaseRef(raw map[any]any, from string, to string) {
+	ref, ok := raw["ref"].(string)
+	if !ok || ref == "" {
+		return
+	}
+
+	if u, err := url.Parse(ref); err != nil || u.IsAbs() || filepath.IsAbs(ref) {
+		return
+	}
+
+	target := filepath.Join(filepath.Dir(from), filepath.FromSlash(ref))
+
+	rel, err := filepath.Rel(filepath.Dir(to), target)
+	if err != nil {
+		return
+	}
+
+	raw["ref"] = filepath.ToSlash(rel)
+}
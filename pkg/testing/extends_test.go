@@ -0,0 +1,255 @@
+package testing_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	stdtesting "testing"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func writeFiles(t *stdtesting.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(name, []byte(strings.TrimSpace(content)+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+}
+
+func newFileSystemCase(t *stdtesting.T, name string) (testing2.Case, error) {
+	t.Helper()
+
+	u, err := url.Parse(name)
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+
+	src, err := sources.NewFileSystem(u)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read suite: %v", err)
+	}
+
+	return testing2.New(testing2.Options{
+		File: sources.File{Source: src, Name: name, Content: content},
+	})
+}
+
+func TestSuiteExtendsMergesBaseManifests(t *stdtesting.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"shared/common.yaml": `
+query:
+  params:
+    retries: 3
+`,
+		"shared/base.yaml": `
+extends: ./common.yaml
+query:
+  text: RETURN "base"
+  params:
+    locale: en
+assert:
+  ref: ./scripts/check.fql
+`,
+		"shared/scripts/check.fql": `RETURN "check"`,
+		"tests/page.yaml": `
+extends: ../shared/base.yaml
+query:
+  text: RETURN "page"
+  params:
+    locale: de
+`,
+	})
+
+	testCase, err := newFileSystemCase(t, filepath.Join(dir, "tests", "page.yaml"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var queries []string
+	var queryParams map[string]any
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+		queries = append(queries, strings.TrimSpace(query.Content()))
+
+		if len(queries) == 1 {
+			queryParams = params
+		}
+
+		return []byte(`true`), nil
+	})
+
+	if err := testCase.Run(context.Background(), rt, testing2.NewParams()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if strings.Join(queries, ", ") != `RETURN "page", RETURN "check"` {
+		t.Fatalf("expected the suite query and the base assertion, got %v", queries)
+	}
+
+	if queryParams["locale"] != "de" || queryParams["retries"] != 3 {
+		t.Fatalf("expected query params to be deep-merged, got %v", queryParams)
+	}
+}
+
+func TestSuiteExtendsReportsChain(t *stdtesting.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"a.yaml":      "extends: ./b.yaml\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true",
+		"b.yaml":      "extends: ./a.yaml",
+		"broken.yaml": "extends: ./none.yaml",
+	})
+
+	name := func(file string) string {
+		return filepath.Join(dir, file)
+	}
+
+	for file, expected := range map[string]string{
+		"a.yaml":      "extends " + name("a.yaml") + " -> " + name("b.yaml") + " -> " + name("a.yaml") + ": cycle detected",
+		"broken.yaml": "extends " + name("broken.yaml") + ": resolve 'ref': ",
+	} {
+		_, err := newFileSystemCase(t, name(file))
+
+		if err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Fatalf("%s: expected error starting with %q, got %v", file, expected, err)
+		}
+	}
+}
+
+func TestSuiteExtendsReplacesScriptSources(t *stdtesting.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"shared/base.yaml": `
+query:
+  ref: ./scripts/base.fql
+  params:
+    locale: en
+assert:
+  text: RETURN "base assert"
+`,
+		"shared/scripts/base.fql": `RETURN "base"`,
+		"tests/page.yaml": `
+extends: ../shared/base.yaml
+query:
+  text: RETURN "page"
+assert:
+  ref: ../shared/scripts/check.fql
+`,
+		"shared/scripts/check.fql": `RETURN "check"`,
+	})
+
+	testCase, err := newFileSystemCase(t, filepath.Join(dir, "tests", "page.yaml"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var queries []string
+	var queryParams map[string]any
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+		queries = append(queries, strings.TrimSpace(query.Content()))
+
+		if len(queries) == 1 {
+			queryParams = params
+		}
+
+		return []byte(`true`), nil
+	})
+
+	if err := testCase.Run(context.Background(), rt, testing2.NewParams()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if strings.Join(queries, ", ") != `RETURN "page", RETURN "check"` {
+		t.Fatalf("expected the suite scripts to replace the base scripts, got %v", queries)
+	}
+
+	if queryParams["locale"] != "en" {
+		t.Fatalf("expected the base query params to be kept, got %v", queryParams)
+	}
+}
+
+func TestSuiteExtendsSwitchesBetweenAssertAndExpect(t *stdtesting.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"base-assert.yaml": `
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`,
+		"base-expect.yaml": `
+query:
+  text: RETURN 1
+expect:
+  error:
+    contains: boom
+`,
+		"expects-error.yaml": `
+extends: ./base-assert.yaml
+expect:
+  error:
+    contains: boom
+`,
+		"asserts.yaml": `
+extends: ./base-expect.yaml
+assert:
+  text: RETURN true
+`,
+	})
+
+	for file, fails := range map[string]bool{
+		"expects-error.yaml": true,
+		"asserts.yaml":       false,
+	} {
+		testCase, err := newFileSystemCase(t, filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", file, err)
+		}
+
+		calls := 0
+
+		rt := labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+			calls++
+
+			if fails {
+				return nil, errors.New("boom")
+			}
+
+			return []byte(`true`), nil
+		})
+
+		if err := testCase.Run(context.Background(), rt, testing2.NewParams()); err != nil {
+			t.Fatalf("%s: expected no error, got %v", file, err)
+		}
+
+		if expected := map[bool]int{true: 1, false: 2}[fails]; calls != expected {
+			t.Fatalf("%s: expected %d runtime calls, got %d", file, expected, calls)
+		}
+	}
+}
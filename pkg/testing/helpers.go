@@ -1,6 +1,9 @@
 package testing
 
-import "reflect"
+import (
+	"reflect"
+	"strings"
+)
 
 func ToMap(m map[string]any) map[string]any {
 	cp := make(map[string]any)
@@ -28,12 +31,16 @@ func TryToMap(input any) any {
 			field := t.Type().Field(x)
 
 			name := field.Name
-			tag := field.Tag.Get("json")
+			tag, options, _ := strings.Cut(field.Tag.Get("json"), ",")
 
 			if tag != "" {
 				name = tag
 			}
 
+			if options == "omitempty" && fieldValue.IsZero() {
+				continue
+			}
+
 			sm[name] = TryToMap(fieldValue.Interface())
 		}
 
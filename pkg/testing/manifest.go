@@ -3,16 +3,48 @@ package testing
 import (
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
 )
 
 type (
 	SuiteManifest struct {
-		Timeout uint64              `yaml:"timeout"`
-		Query   ScriptManifest      `yaml:"query"`
-		Assert  *ScriptManifest     `yaml:"assert"`
-		Expect  ExpectationManifest `yaml:"expect"`
+		// Extends references a base manifest, resolved relative to the suite,
+		// that the suite manifest is deep-merged over.
+		Extends string   `yaml:"extends"`
+		Timeout uint64   `yaml:"timeout"`
+		Tags    []string `yaml:"tags"`
+		// Skip disables the suite; the value is reported as the skip reason.
+		Skip string `yaml:"skip"`
+		// RuntimeVersion is a version constraint the runtime must satisfy,
+		// e.g. ">=2.1.0, <3"; the suite is skipped otherwise.
+		RuntimeVersion string `yaml:"runtimeVersion"`
+		// DependsOn lists the suites, relative to this one, that must pass
+		// before this suite runs; the suite is skipped otherwise.
+		DependsOn []string `yaml:"dependsOn"`
+		// Setup runs before the query; its output is exposed to the query,
+		// assertion and teardown as @lab.data.setup.result.
+		Setup *ScriptManifest `yaml:"setup"`
+		// Teardown runs after the other phases, even when one of them failed.
+		Teardown *ScriptManifest     `yaml:"teardown"`
+		Query    ScriptManifest      `yaml:"query"`
+		Assert   *ScriptManifest     `yaml:"assert"`
+		Expect   ExpectationManifest `yaml:"expect"`
+		// Cases splits the suite into named cases. The top-level query, assert
+		// and expect act as shared defaults for cases that do not set their own.
+		Cases []CaseManifest `yaml:"cases"`
+		// Matrix runs the suite, or every case, once per param combination.
+		Matrix *MatrixManifest `yaml:"matrix"`
+	}
+
+	CaseManifest struct {
+		Name   string              `yaml:"name"`
+		Tags   []string            `yaml:"tags"`
+		Skip   string              `yaml:"skip"`
+		Query  *ScriptManifest     `yaml:"query"`
+		Assert *ScriptManifest     `yaml:"assert"`
+		Expect ExpectationManifest `yaml:"expect"`
+		// Params are merged over the params of every script of the suite.
+		Params map[string]any `yaml:"params"`
 	}
 
 	ScriptManifest struct {
@@ -23,53 +55,127 @@ type (
 
 	ExpectationManifest struct {
 		Error *ErrorExpectationManifest `yaml:"error,omitempty"`
-	}
-
-	ErrorExpectationManifest struct {
-		Contains string `yaml:"contains,omitempty"`
+		// Result holds declarative assertions on the deserialized query result.
+		Result []ResultExpectationManifest `yaml:"result,omitempty"`
+		// Schema validates the query result against a JSON Schema.
+		Schema *SchemaExpectationManifest `yaml:"schema,omitempty"`
+		// Snapshot compares the whole query output with a stored snapshot.
+		Snapshot *SnapshotExpectationManifest `yaml:"snapshot,omitempty"`
 	}
 )
 
-// UnmarshalYAML rejects unknown nested fields without enabling strict decoding
-// for the rest of the suite manifest.
-func (manifest *ErrorExpectationManifest) UnmarshalYAML(unmarshal func(any) error) error {
-	decoded := struct {
-		Contains string         `yaml:"contains,omitempty"`
-		Unknown  map[string]any `yaml:",inline"`
-	}{}
-
-	if err := unmarshal(&decoded); err != nil {
+func (manifest SuiteManifest) validate() error {
+	if err := validateTags(manifest.Tags); err != nil {
 		return err
 	}
 
-	if len(decoded.Unknown) > 0 {
-		fields := make([]string, 0, len(decoded.Unknown))
+	if err := manifest.Matrix.validate(); err != nil {
+		return err
+	}
 
-		for field := range decoded.Unknown {
-			fields = append(fields, field)
+	for _, dep := range manifest.DependsOn {
+		if strings.TrimSpace(dep) == "" {
+			return errors.New("dependsOn: dependency cannot be empty")
 		}
+	}
 
-		sort.Strings(fields)
+	if len(manifest.Cases) > 0 {
+		return manifest.validateCases()
+	}
+
+	return manifest.validateScripts()
+}
+
+func (manifest SuiteManifest) validateCases() error {
+	names := make(map[string]bool, len(manifest.Cases))
+
+	for i, c := range manifest.Cases {
+		name := strings.TrimSpace(c.Name)
 
-		if len(fields) == 1 {
-			return fmt.Errorf("expect.error contains unsupported field %q", fields[0])
+		if name == "" {
+			return fmt.Errorf("cases[%d]: name cannot be empty", i)
 		}
 
-		quotedFields := make([]string, len(fields))
+		if names[name] {
+			return fmt.Errorf("cases[%d]: duplicate case name %q", i, name)
+		}
+
+		names[name] = true
 
-		for i, field := range fields {
-			quotedFields[i] = fmt.Sprintf("%q", field)
+		if err := validateTags(c.Tags); err != nil {
+			return fmt.Errorf("cases[%d]: %w", i, err)
 		}
 
-		return fmt.Errorf("expect.error contains unsupported fields %s", strings.Join(quotedFields, ", "))
+		if err := manifest.forCase(c).validateScripts(); err != nil {
+			return fmt.Errorf("cases[%d] %q: %w", i, name, err)
+		}
 	}
 
-	manifest.Contains = decoded.Contains
-
 	return nil
 }
 
-func (manifest SuiteManifest) validate() error {
+// forCase resolves the manifest of a single case by applying it over the
+// shared top-level values.
+func (manifest SuiteManifest) forCase(c CaseManifest) SuiteManifest {
+	resolved := manifest
+	resolved.Cases = nil
+	resolved.Matrix = nil
+	resolved.Tags = append(append([]string{}, manifest.Tags...), c.Tags...)
+
+	if c.Skip != "" {
+		resolved.Skip = c.Skip
+	}
+
+	if c.Query != nil {
+		resolved.Query = *c.Query
+	}
+
+	// assertion and expectation are replaced together so that a case can switch
+	// between asserting a result and expecting an error
+	if c.Assert != nil || !c.Expect.empty() {
+		resolved.Assert = c.Assert
+		resolved.Expect = c.Expect
+	}
+
+	return resolved.withParams(c.Params)
+}
+
+// forCombination merges a matrix combination into the script params.
+func (manifest SuiteManifest) forCombination(combination matrixCombination) SuiteManifest {
+	resolved := manifest
+	resolved.Cases = nil
+	resolved.Matrix = nil
+
+	return resolved.withParams(combination.params())
+}
+
+// withParams merges params into every script of the manifest.
+func (manifest SuiteManifest) withParams(params map[string]any) SuiteManifest {
+	manifest.Query = manifest.Query.withParams(params)
+
+	for _, script := range []**ScriptManifest{&manifest.Setup, &manifest.Teardown, &manifest.Assert} {
+		if *script != nil {
+			merged := (*script).withParams(params)
+			*script = &merged
+		}
+	}
+
+	return manifest
+}
+
+func (manifest SuiteManifest) validateScripts() error {
+	if manifest.Setup != nil {
+		if err := manifest.Setup.validate(); err != nil {
+			return fmt.Errorf("setup: %w", err)
+		}
+	}
+
+	if manifest.Teardown != nil {
+		if err := manifest.Teardown.validate(); err != nil {
+			return fmt.Errorf("teardown: %w", err)
+		}
+	}
+
 	if err := manifest.Query.validate(); err != nil {
 		return fmt.Errorf("query: %w", err)
 	}
@@ -79,6 +185,23 @@ func (manifest SuiteManifest) validate() error {
 			return errors.New("expect.error cannot be combined with assert")
 		}
 
+		if len(manifest.Expect.Result) > 0 {
+			return errors.New("expect.error cannot be combined with expect.result")
+		}
+
+		if manifest.Expect.Schema != nil {
+			return errors.New("expect.error cannot be combined with expect.schema")
+		}
+
+		if manifest.Expect.Snapshot != nil {
+			return errors.New("expect.error cannot be combined with expect.snapshot")
+		}
+
+		return nil
+	}
+
+	// declarative expectations make the assertion script optional
+	if manifest.Assert == nil && manifest.Expect.declarative() {
 		return nil
 	}
 
@@ -93,6 +216,47 @@ func (manifest SuiteManifest) validate() error {
 	return nil
 }
 
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return errors.New("tags: tag cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// withParams returns a copy of the script with params merged over its own.
+func (manifest ScriptManifest) withParams(params map[string]any) ScriptManifest {
+	if len(params) == 0 {
+		return manifest
+	}
+
+	merged := make(map[string]any, len(manifest.Params)+len(params))
+
+	for key, value := range manifest.Params {
+		merged[key] = value
+	}
+
+	for key, value := range params {
+		merged[key] = value
+	}
+
+	manifest.Params = merged
+
+	return manifest
+}
+
+func (manifest ExpectationManifest) empty() bool {
+	return manifest.Error == nil && !manifest.declarative()
+}
+
+// declarative reports whether the expectations check the query result
+// without an assertion script.
+func (manifest ExpectationManifest) declarative() bool {
+	return len(manifest.Result) > 0 || manifest.Schema != nil || manifest.Snapshot != nil
+}
+
 func (manifest ScriptManifest) validate() error {
 	if manifest.Ref == "" && manifest.Text == "" {
 		return errors.New("ref or text must have value")
@@ -110,15 +274,3 @@ func (manifest ScriptManifest) runtimeParams(params Params) map[string]any {
 
 	return params.ToMap()
 }
-
-func (manifest ErrorExpectationManifest) evaluate(actual error) error {
-	if actual == nil {
-		return errors.New("expected query to fail, but it completed successfully")
-	}
-
-	if manifest.Contains != "" && !strings.Contains(actual.Error(), manifest.Contains) {
-		return fmt.Errorf("expected error containing %q, got: %v", manifest.Contains, actual)
-	}
-
-	return nil
-}
@@ -0,0 +1,127 @@
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// MatrixManifest expands a suite into one execution per combination of
+	// params. It is declared either as a list of param maps or as named axes
+	// whose cartesian product forms the combinations.
+	MatrixManifest struct {
+		Include []yaml.MapSlice
+		Axes    yaml.MapSlice
+	}
+
+	// matrixCombination is an ordered set of params, kept in declaration order
+	// so that result names are stable.
+	matrixCombination []yaml.MapItem
+)
+
+// UnmarshalYAML accepts both the list and the axes form.
+func (manifest *MatrixManifest) UnmarshalYAML(unmarshal func(any) error) error {
+	var include []yaml.MapSlice
+
+	if err := unmarshal(&include); err == nil {
+		manifest.Include = include
+
+		return nil
+	}
+
+	var axes yaml.MapSlice
+
+	if err := unmarshal(&axes); err != nil {
+		return errors.New("matrix must be a list of params or a map of axes")
+	}
+
+	manifest.Axes = axes
+
+	return nil
+}
+
+func (manifest *MatrixManifest) validate() error {
+	if manifest == nil {
+		return nil
+	}
+
+	if len(manifest.Include) == 0 && len(manifest.Axes) == 0 {
+		return errors.New("matrix: must declare at least one combination")
+	}
+
+	for i, params := range manifest.Include {
+		if len(params) == 0 {
+			return fmt.Errorf("matrix[%d]: params cannot be empty", i)
+		}
+	}
+
+	for _, axis := range manifest.Axes {
+		values, ok := axis.Value.([]any)
+
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("matrix.%v: axis must be a non-empty list", axis.Key)
+		}
+	}
+
+	return nil
+}
+
+// combinations returns the param combinations in declaration order; for axes
+// the last axis varies fastest.
+func (manifest *MatrixManifest) combinations() []matrixCombination {
+	if manifest == nil {
+		return nil
+	}
+
+	if len(manifest.Include) > 0 {
+		out := make([]matrixCombination, 0, len(manifest.Include))
+
+		for _, params := range manifest.Include {
+			out = append(out, matrixCombination(params))
+		}
+
+		return out
+	}
+
+	out := []matrixCombination{{}}
+
+	for _, axis := range manifest.Axes {
+		values, _ := axis.Value.([]any)
+		next := make([]matrixCombination, 0, len(out)*len(values))
+
+		for _, combination := range out {
+			for _, value := range values {
+				extended := append(append(matrixCombination{}, combination...), yaml.MapItem{Key: axis.Key, Value: value})
+				next = append(next, extended)
+			}
+		}
+
+		out = next
+	}
+
+	return out
+}
+
+func (combination matrixCombination) params() map[string]any {
+	params := make(map[string]any, len(combination))
+
+	for _, item := range combination {
+		params[fmt.Sprint(item.Key)] = item.Value
+	}
+
+	return params
+}
+
+// label formats the combination for result names, e.g. "[locale=en, url=https://a]".
+func (combination matrixCombination) label() string {
+	parts := make([]string, 0, len(combination))
+
+	for _, item := range combination {
+		parts = append(parts, fmt.Sprintf("%v=%v", item.Key, item.Value))
+	}
+
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,157 @@
+package testing_test
+
+import (
+	"context"
+	"strings"
+	stdtesting "testing"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestSuiteMatrixExpandsCombinations(t *stdtesting.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expected []string
+		params   []map[string]any
+	}{
+		{
+			name: "list",
+			manifest: `
+matrix:
+  - url: https://a.example
+    locale: en
+  - url: https://b.example
+    locale: de
+`,
+			expected: []string{
+				"scrape.yaml[url=https://a.example, locale=en]",
+				"scrape.yaml[url=https://b.example, locale=de]",
+			},
+			params: []map[string]any{
+				{"url": "https://a.example", "locale": "en", "shared": 1},
+				{"url": "https://b.example", "locale": "de", "shared": 1},
+			},
+		},
+		{
+			name: "axes",
+			manifest: `
+matrix:
+  locale: [en, de]
+  page: [1, 2]
+`,
+			expected: []string{
+				"scrape.yaml[locale=en, page=1]",
+				"scrape.yaml[locale=en, page=2]",
+				"scrape.yaml[locale=de, page=1]",
+				"scrape.yaml[locale=de, page=2]",
+			},
+			params: []map[string]any{
+				{"locale": "en", "page": 1, "shared": 1},
+				{"locale": "en", "page": 2, "shared": 1},
+				{"locale": "de", "page": 1, "shared": 1},
+				{"locale": "de", "page": 2, "shared": 1},
+			},
+		},
+		{
+			name: "cases",
+			manifest: `
+matrix:
+  locale: [en, de]
+cases:
+  - name: title
+  - name: footer
+`,
+			expected: []string{
+				"scrape.yaml#title[locale=en]",
+				"scrape.yaml#title[locale=de]",
+				"scrape.yaml#footer[locale=en]",
+				"scrape.yaml#footer[locale=de]",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			testCase, err := testing2.New(testing2.Options{
+				File: sources.File{
+					Name: "scrape.yaml",
+					Content: []byte(test.manifest + `
+query:
+  text: RETURN 1
+  params:
+    shared: 1
+assert:
+  text: RETURN true
+`),
+				},
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			cases := testCase.(*testing2.Suite).Cases()
+
+			if len(cases) != len(test.expected) {
+				t.Fatalf("expected %d cases, got %d", len(test.expected), len(cases))
+			}
+
+			for i, name := range test.expected {
+				if cases[i].Name != name {
+					t.Fatalf("expected case %d to be named %q, got %q", i, name, cases[i].Name)
+				}
+			}
+
+			for i, expected := range test.params {
+				var got map[string]any
+
+				rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+					if query.Content() == "RETURN 1" {
+						got = params
+					}
+
+					return []byte(`true`), nil
+				})
+
+				if err := cases[i].Case.Run(context.Background(), rt, testing2.NewParams()); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				for key, value := range expected {
+					if got[key] != value {
+						t.Fatalf("case %d: expected param %s=%v, got %v", i, key, value, got[key])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSuiteMatrixValidation(t *stdtesting.T) {
+	tests := map[string]string{
+		"matrix: []":              "matrix: must declare at least one combination",
+		"matrix: [{}]":            "matrix[0]: params cannot be empty",
+		"matrix:\n  locale: en":   "matrix.locale: axis must be a non-empty list",
+		"matrix:\n  locale: []":   "matrix.locale: axis must be a non-empty list",
+		"matrix: text":            "matrix must be a list of params or a map of axes",
+		"matrix:\n  - 1\n  - 2\n": "matrix must be a list of params or a map of axes",
+	}
+
+	for manifest, expected := range tests {
+		_, err := testing2.New(testing2.Options{
+			File: sources.File{
+				Name:    "suite.yaml",
+				Content: []byte(manifest + "\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+			},
+		})
+
+		// parse errors are wrapped, so only the end of the message is stable
+		if err == nil || !strings.HasSuffix(err.Error(), expected) {
+			t.Fatalf("%q: expected error %q, got %v", manifest, expected, err)
+		}
+	}
+}
@@ -21,6 +21,21 @@ func (p *Params) SetSystemValue(name string, value any) {
 	p.system[name] = value
 }
 
+// SetDataValue stores the values of one execution phase under "lab.data",
+// keeping the values of the phases that ran before it.
+func (p *Params) SetDataValue(phase string, values DataContextValues) {
+	data := make(map[string]any)
+
+	if current, ok := TryToMap(p.system["data"]).(map[string]any); ok {
+		for k, v := range current {
+			data[k] = v
+		}
+	}
+
+	data[phase] = TryToMap(values)
+	p.system["data"] = data
+}
+
 func (p *Params) SetUserValue(name string, value any) {
 	p.user[name] = value
 }
@@ -84,6 +99,41 @@ func (p *Params) ToMap() map[string]any {
 	return out
 }
 
+// Inherit returns a copy of the params completed with the user values and
+// the data values of the execution phases of the parent that the params do
+// not define themselves.
+func (p *Params) Inherit(parent Params) Params {
+	out := p.Clone()
+	inherited := parent.Clone()
+
+	for name, value := range inherited.user {
+		if _, found := out.user[name]; !found {
+			out.user[name] = value
+		}
+	}
+
+	parentData, ok := inherited.system["data"].(map[string]any)
+	if !ok || len(parentData) == 0 {
+		return out
+	}
+
+	data := make(map[string]any, len(parentData))
+
+	for phase, values := range parentData {
+		data[phase] = values
+	}
+
+	if current, ok := out.system["data"].(map[string]any); ok {
+		for phase, values := range current {
+			data[phase] = values
+		}
+	}
+
+	out.system["data"] = data
+
+	return out
+}
+
 func (p *Params) Clone() Params {
 	return Params{
 		system: ToMap(p.system),
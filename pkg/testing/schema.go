@@ -0,0 +1,295 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlclark/regexp2"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// schemaBase is the location schemas are compiled at. It only serves to
+	// resolve references within a schema: no other document is ever loaded.
+	schemaBase       = "lab:///"
+	schemaURL        = schemaBase + "schema.json"
+	schemaTimeout    = time.Second
+	maxSchemaFailure = 100
+)
+
+var schemaPrinter = message.NewPrinter(language.English)
+
+type (
+	// SchemaExpectationManifest validates the query result against a JSON
+	// Schema declared inline or referenced by Ref, which is resolved relative
+	// to the suite like script references.
+	SchemaExpectationManifest struct {
+		Ref    string
+		Inline any
+
+		schema *JSONSchema
+	}
+
+	// JSONSchema is a compiled JSON Schema. Documents are validated as draft
+	// 2020-12 unless they declare another draft with "$schema". References are
+	// resolved within the schema document; references to other documents are
+	// rejected.
+	JSONSchema struct {
+		compiled *jsonschema.Schema
+	}
+
+	// schemaLoader refuses to load the documents a schema refers to.
+	schemaLoader struct{}
+
+	// schemaPattern matches "pattern" and "patternProperties" with ECMA-262
+	// semantics, as the specification requires.
+	schemaPattern regexp2.Regexp
+)
+
+// UnmarshalYAML accepts either {ref: <path>} or an inline schema, which is
+// compiled right away so that invalid schemas fail during suite construction.
+func (manifest *SchemaExpectationManifest) UnmarshalYAML(unmarshal func(any) error) error {
+	var value any
+
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	value = normalizeValue(value)
+
+	if object, ok := value.(map[string]any); ok && len(object) == 1 {
+		if ref, exists := object["ref"]; exists {
+			text, ok := ref.(string)
+			if !ok || text == "" {
+				return errors.New("expect.schema: ref must be a non-empty string")
+			}
+
+			*manifest = SchemaExpectationManifest{Ref: text}
+
+			return nil
+		}
+	}
+
+	schema, err := CompileJSONSchema(value)
+	if err != nil {
+		return fmt.Errorf("expect.schema: %w", err)
+	}
+
+	*manifest = SchemaExpectationManifest{Inline: value, schema: schema}
+
+	return nil
+}
+
+func (suite *Suite) evaluateSchema(ctx context.Context, output any) error {
+	manifest := suite.manifest.Expect.Schema
+	schema := manifest.schema
+
+	if manifest.Ref != "" {
+		f, err := resolveRef(ctx, suite.file, manifest.Ref)
+		if err != nil {
+			return err
+		}
+
+		var document any
+
+		if err := json.Unmarshal(f.Content, &document); err != nil {
+			if yamlErr := yaml.Unmarshal(f.Content, &document); yamlErr != nil {
+				return fmt.Errorf("parse %s: %w", manifest.Ref, err)
+			}
+		}
+
+		schema, err = CompileJSONSchema(document)
+		if err != nil {
+			return fmt.Errorf("%s: %w", manifest.Ref, err)
+		}
+	}
+
+	return schemaError(schema.Validate(output))
+}
+
+// CompileJSONSchema compiles a decoded schema document, either a boolean or an
+// object, and reports schemas that are invalid against their metaschema,
+// invalid patterns and unresolvable references.
+func CompileJSONSchema(document any) (*JSONSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(schemaLoader{})
+	compiler.UseRegexpEngine(compileSchemaPattern)
+
+	if err := compiler.AddResource(schemaURL, normalizeValue(document)); err != nil {
+		return nil, schemaCompileError(err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, schemaCompileError(err)
+	}
+
+	return &JSONSchema{compiled: compiled}, nil
+}
+
+// Validate checks the instance, a value decoded from JSON, and returns every
+// violation prefixed with its instance path, e.g. "$.items[0].price: ...".
+func (schema *JSONSchema) Validate(instance any) []string {
+	err := schema.compiled.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	var validation *jsonschema.ValidationError
+
+	if !errors.As(err, &validation) {
+		return []string{"$: " + err.Error()}
+	}
+
+	failures := make([]string, 0)
+
+	collectSchemaFailures(validation, true, func(failure *jsonschema.ValidationError) {
+		failures = append(failures, schemaInstancePath(instance, failure.InstanceLocation)+": "+schemaMessage(failure))
+	})
+
+	sort.Strings(failures)
+
+	return failures
+}
+
+// collectSchemaFailures reports the innermost violations. The alternatives of
+// anyOf, oneOf and contains are not requirements on their own, so with whole
+// set those violations are reported as a whole.
+func collectSchemaFailures(err *jsonschema.ValidationError, whole bool, report func(*jsonschema.ValidationError)) {
+	switch err.ErrorKind.(type) {
+	case *kind.AnyOf, *kind.OneOf, *kind.Contains:
+		if whole {
+			report(err)
+
+			return
+		}
+	}
+
+	if len(err.Causes) == 0 {
+		report(err)
+
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectSchemaFailures(cause, whole, report)
+	}
+}
+
+func schemaMessage(err *jsonschema.ValidationError) string {
+	if _, ok := err.ErrorKind.(*kind.FalseSchema); ok {
+		return "not allowed by the schema"
+	}
+
+	return err.ErrorKind.LocalizedString(schemaPrinter)
+}
+
+// schemaInstancePath formats the JSON pointer tokens of an instance location
+// as a selector path, using the instance to tell array indexes from names.
+func schemaInstancePath(instance any, location []string) string {
+	path := "$"
+
+	for _, token := range location {
+		switch value := instance.(type) {
+		case []any:
+			path += "[" + token + "]"
+
+			if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(value) {
+				instance = value[index]
+			}
+		case map[string]any:
+			path = childPath(path, token)
+			instance = value[token]
+		default:
+			path = childPath(path, token)
+			instance = nil
+		}
+	}
+
+	return path
+}
+
+// schemaCompileError reports the violations of an invalid schema with their
+// location in the schema document, e.g. "#/properties/id/minimum: ...".
+func schemaCompileError(err error) error {
+	var invalid *jsonschema.SchemaValidationError
+	var validation *jsonschema.ValidationError
+
+	if errors.As(err, &invalid) && errors.As(invalid.Err, &validation) {
+		failures := make([]string, 0)
+
+		collectSchemaFailures(validation, false, func(failure *jsonschema.ValidationError) {
+			failures = append(failures, schemaLocation(failure.InstanceLocation)+": "+schemaMessage(failure))
+		})
+
+		sort.Strings(failures)
+
+		return errors.New(strings.Join(failures, "\n"))
+	}
+
+	return errors.New(strings.NewReplacer(schemaURL, "", schemaBase, "").Replace(err.Error()))
+}
+
+func schemaLocation(location []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	location = slices.Clone(location)
+
+	for i, token := range location {
+		location[i] = escaper.Replace(token)
+	}
+
+	return "#" + strings.Join(slices.Insert(location, 0, ""), "/")
+}
+
+func (schemaLoader) Load(string) (any, error) {
+	return nil, errors.New("references to other documents are not supported")
+}
+
+func compileSchemaPattern(pattern string) (jsonschema.Regexp, error) {
+	compiled, err := regexp2.Compile(pattern, regexp2.ECMAScript|regexp2.Unicode)
+	if err != nil {
+		return nil, err
+	}
+
+	// a pattern that backtracks for too long fails the match instead of hanging the run
+	compiled.MatchTimeout = schemaTimeout
+
+	return (*schemaPattern)(compiled), nil
+}
+
+func (pattern *schemaPattern) MatchString(value string) bool {
+	matched, err := (*regexp2.Regexp)(pattern).MatchString(value)
+
+	return err == nil && matched
+}
+
+func (pattern *schemaPattern) String() string {
+	return (*regexp2.Regexp)(pattern).String()
+}
+
+// schemaError joins the violations, keeping the report readable for large
+// documents.
+func schemaError(failures []string) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	if len(failures) > maxSchemaFailure {
+		more := len(failures) - maxSchemaFailure
+		failures = append(failures[:maxSchemaFailure:maxSchemaFailure], fmt.Sprintf("... and %d more violations", more))
+	}
+
+	return errors.New(strings.Join(failures, "\n"))
+}
@@ -0,0 +1,304 @@
+package testing_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	stdtesting "testing"
+
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+const productSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "title", "price", "tags"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"title": {"type": "string", "minLength": 3, "pattern": "^[A-Z]"},
+		"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01},
+		"currency": {"enum": ["EUR", "USD"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"variants": {"type": "array", "items": {"$ref": "#/$defs/variant"}, "contains": {"const": "default"}},
+		"seller": {"$ref": "#seller"}
+	},
+	"dependentRequired": {"currency": ["price"]},
+	"$defs": {
+		"variant": {"oneOf": [{"type": "string"}, {"type": "object", "required": ["name"]}]},
+		"seller": {"$anchor": "seller", "type": ["object", "null"], "properties": {"name": {"type": "string"}}}
+	}
+}`
+
+func TestJSONSchemaValidate(t *stdtesting.T) {
+	schema := compileSchema(t, productSchema)
+
+	tests := []struct {
+		name     string
+		instance string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			instance: `{"id": 1, "title": "Lamp", "price": 19.99, "currency": "EUR", "tags": ["home"], "variants": ["default", {"name": "red"}], "seller": null}`,
+		},
+		{
+			name:     "every violation with its instance path",
+			instance: `{"id": 1.5, "title": "la", "price": 0, "currency": "GBP", "tags": ["a", "a", 3, "b"], "variants": [{"color": "red"}], "seller": {"name": 1}, "extra": true}`,
+			expected: []string{
+				`$.currency: value must be one of 'EUR', 'USD'`,
+				`$.id: got number, want integer`,
+				`$.price: exclusiveMinimum: got 0, want 0`,
+				`$.seller.name: got number, want string`,
+				`$.tags: items at 0 and 1 are equal`,
+				`$.tags: maxItems: got 4, want 3`,
+				`$.tags[2]: got number, want string`,
+				`$.title: 'la' does not match pattern '^[A-Z]'`,
+				`$.title: minLength: got 2, want 3`,
+				`$.variants: no items match contains schema`,
+				`$.variants[0]: 'oneOf' failed, none matched`,
+				`$: additional properties 'extra' not allowed`,
+			},
+		},
+		{
+			name:     "missing properties",
+			instance: `{"currency": "EUR"}`,
+			expected: []string{
+				`$: missing properties 'id', 'title', 'price', 'tags'`,
+				`$: properties 'price' required, if 'currency' exists`,
+			},
+		},
+		{
+			name:     "wrong root type",
+			instance: `[1]`,
+			expected: []string{`$: got array, want object`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			var instance any
+
+			if err := json.Unmarshal([]byte(test.instance), &instance); err != nil {
+				t.Fatalf("failed to decode instance: %v", err)
+			}
+
+			failures := schema.Validate(instance)
+
+			if strings.Join(failures, "\n") != strings.Join(test.expected, "\n") {
+				t.Fatalf("unexpected failures:\n%s\nexpected:\n%s", strings.Join(failures, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestCompileJSONSchemaRejectsInvalidSchemas(t *stdtesting.T) {
+	tests := map[string]string{
+		`{"$schema": "https://example.com/custom"}`:  `failing loading "https://example.com/custom": references to other documents are not supported`,
+		`{"$ref": "other.json#/$defs/a"}`:            `failing loading "other.json": references to other documents are not supported`,
+		`{"$ref": "#/$defs/missing"}`:                `json-pointer in "#/$defs/missing" not found`,
+		`{"properties": {"a": {"pattern": "("}}}`:    `#/properties/a/pattern: '(' is not valid regex`,
+		`{"items": {"type": "text"}}`:                `#/items/type: value must be one of 'array', 'boolean', 'integer', 'null', 'number', 'object', 'string'`,
+		`{"minLength": -1}`:                          `#/minLength: minimum: got -1, want 0`,
+		`{"anyOf": []}`:                              `#/anyOf: minItems: got 0, want 1`,
+		`{"properties": {"a": 1}}`:                   `#/properties/a: got number, want boolean or object`,
+		`{"properties": {"a/b": {"minLength": ""}}}`: `#/properties/a~1b/minLength: got string, want integer`,
+	}
+
+	for text, expected := range tests {
+		var document any
+
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			t.Fatalf("failed to decode schema: %v", err)
+		}
+
+		_, err := testing2.CompileJSONSchema(document)
+
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%s: expected error containing %q, got %v", text, expected, err)
+		}
+	}
+}
+
+func TestSuiteExpectSchema(t *stdtesting.T) {
+	dir := t.TempDir()
+	output := `{"id": 0, "title": "Lamp", "price": 19.99, "tags": []}`
+
+	if err := os.WriteFile(filepath.Join(dir, "product.schema.json"), []byte(productSchema), 0o644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "item.schema.yaml"), []byte("type: object\nrequired: [sku]\n"), 0o644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expect   string
+		expected string
+	}{
+		{
+			name:     "inline",
+			expect:   "schema:\n    type: object\n    properties:\n      id: {type: integer, minimum: 1}\n      tags: {minItems: 1}",
+			expected: "expect.schema: $.id: minimum: got 0, want 1\n$.tags: minItems: got 0, want 1",
+		},
+		{
+			name:     "json ref",
+			expect:   "schema:\n    ref: ./product.schema.json",
+			expected: "expect.schema: $.id: minimum: got 0, want 1",
+		},
+		{
+			name:     "yaml ref",
+			expect:   "schema:\n    ref: ./item.schema.yaml",
+			expected: `expect.schema: $: missing property 'sku'`,
+		},
+		{
+			name:     "missing ref",
+			expect:   "schema:\n    ref: ./missing.schema.json",
+			expected: "expect.schema: resolve 'ref': ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			suitePath := filepath.Join(dir, "product.yaml")
+			writeSnapshotSuite(t, suitePath, "query:\n  text: RETURN 1\nexpect:\n  "+test.expect)
+
+			err := runSnapshotSuite(t, suitePath, false, func() string { return output })
+
+			if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+				t.Fatalf("expected error starting with %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestSuiteExpectSchemaValidation(t *stdtesting.T) {
+	tests := map[string]string{
+		"schema:\n    type: text":             "#/type: value must be one of",
+		"schema:\n    ref: 1":                 "expect.schema: ref must be a non-empty string",
+		"error: {}\n  schema: {type: object}": "expect.error cannot be combined with expect.schema",
+	}
+
+	for expect, expected := range tests {
+		_, err := testing2.New(testing2.Options{
+			File: sources.File{
+				Name:    "suite.yaml",
+				Content: []byte("query:\n  text: RETURN 1\nexpect:\n  " + expect + "\n"),
+			},
+		})
+
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%q: expected error containing %q, got %v", expect, expected, err)
+		}
+	}
+}
+
+func compileSchema(t *stdtesting.T, text string) *testing2.JSONSchema {
+	t.Helper()
+
+	var document any
+
+	if err := json.Unmarshal([]byte(text), &document); err != nil {
+		t.Fatalf("failed to decode schema: %v", err)
+	}
+
+	schema, err := testing2.CompileJSONSchema(document)
+	if err != nil {
+		t.Fatalf("failed to compile schema: %v", err)
+	}
+
+	return schema
+}
+
+// TestJSONSchemaConformance runs cases of the JSON Schema Test Suite
+// (draft2020-12) for keywords that depend on the full specification.
+func TestJSONSchemaConformance(t *stdtesting.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{
+			name:     "unevaluatedProperties with adjacent properties",
+			schema:   `{"type": "object", "properties": {"foo": {"type": "string"}}, "unevaluatedProperties": false}`,
+			instance: `{"foo": "foo", "bar": "bar"}`,
+		},
+		{
+			name:     "unevaluatedProperties with nested allOf",
+			schema:   `{"allOf": [{"properties": {"foo": true}}], "unevaluatedProperties": false}`,
+			instance: `{"foo": 1}`,
+			valid:    true,
+		},
+		{
+			name:     "unevaluatedItems with prefixItems",
+			schema:   `{"prefixItems": [{"type": "string"}], "unevaluatedItems": false}`,
+			instance: `["foo", "bar"]`,
+		},
+		{
+			name: "$dynamicRef resolves to the outermost $dynamicAnchor",
+			schema: `{
+				"$id": "https://test.json-schema.org/typical-dynamic-resolution/root",
+				"$ref": "list",
+				"$defs": {
+					"foo": {"$dynamicAnchor": "items", "type": "string"},
+					"list": {
+						"$id": "list",
+						"type": "array",
+						"items": {"$dynamicRef": "#items"},
+						"$defs": {"items": {"$comment": "overridden", "$dynamicAnchor": "items"}}
+					}
+				}
+			}`,
+			instance: `["foo", 42]`,
+		},
+		{
+			name:     "ECMA-262 \\d does not match non-ASCII digits",
+			schema:   `{"type": "string", "pattern": "^\\d$"}`,
+			instance: `"߀"`,
+		},
+		{
+			name:     "ECMA-262 control escape",
+			schema:   `{"type": "string", "pattern": "^\\cC$"}`,
+			instance: "\"\\u0003\"",
+			valid:    true,
+		},
+		{
+			name:     "ECMA-262 lookahead",
+			schema:   `{"type": "string", "pattern": "^(?!admin)[a-z]+$"}`,
+			instance: `"administrator"`,
+		},
+		{
+			name:     "format is an annotation",
+			schema:   `{"format": "email"}`,
+			instance: `"not an email"`,
+			valid:    true,
+		},
+		{
+			name:     "declared draft-07 keeps its semantics",
+			schema:   `{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{"type": "string"}], "additionalItems": false}`,
+			instance: `["foo", "bar"]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			schema := compileSchema(t, test.schema)
+
+			var instance any
+
+			if err := json.Unmarshal([]byte(test.instance), &instance); err != nil {
+				t.Fatalf("failed to decode instance: %v", err)
+			}
+
+			failures := schema.Validate(instance)
+
+			if valid := len(failures) == 0; valid != test.valid {
+				t.Fatalf("expected valid %t, got failures %v", test.valid, failures)
+			}
+		})
+	}
+}
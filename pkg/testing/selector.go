@@ -0,0 +1,199 @@
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Selector is a compiled JSONPath-like expression. It supports the root
+	// "$", child names (".name" or "['name']"), array indexes ("[0]", negative
+	// indexes count from the end) and wildcards (".*" or "[*]").
+	Selector struct {
+		text  string
+		steps []selectorStep
+	}
+
+	selectorStep struct {
+		name     string
+		index    int
+		isIndex  bool
+		wildcard bool
+	}
+
+	// SelectedValue is a value matched by a selector together with its
+	// concrete path.
+	SelectedValue struct {
+		Path  string
+		Value any
+	}
+)
+
+func ParseSelector(text string) (Selector, error) {
+	selector := Selector{text: strings.TrimSpace(text)}
+	rest := selector.text
+
+	if !strings.HasPrefix(rest, "$") {
+		return selector, fmt.Errorf("selector %q must start with $", text)
+	}
+
+	rest = rest[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+
+			if end < 0 {
+				end = len(rest)
+			}
+
+			name := rest[:end]
+			rest = rest[end:]
+
+			if name == "" {
+				return selector, fmt.Errorf("selector %q has an empty name", text)
+			}
+
+			if name == "*" {
+				selector.steps = append(selector.steps, selectorStep{wildcard: true})
+			} else {
+				selector.steps = append(selector.steps, selectorStep{name: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+
+			if end < 0 {
+				return selector, fmt.Errorf("selector %q has an unclosed bracket", text)
+			}
+
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			step, err := parseBracketStep(inner)
+			if err != nil {
+				return selector, fmt.Errorf("selector %q: %w", text, err)
+			}
+
+			selector.steps = append(selector.steps, step)
+		default:
+			return selector, fmt.Errorf("selector %q has unexpected %q", text, rest[0])
+		}
+	}
+
+	return selector, nil
+}
+
+func parseBracketStep(inner string) (selectorStep, error) {
+	if inner == "*" {
+		return selectorStep{wildcard: true}, nil
+	}
+
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return selectorStep{name: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return selectorStep{}, fmt.Errorf("invalid index %q", inner)
+	}
+
+	return selectorStep{index: index, isIndex: true}, nil
+}
+
+// String returns the selector as declared.
+func (selector Selector) String() string {
+	return selector.text
+}
+
+// Select returns every value matched by the selector in document order.
+// Object wildcards visit keys in sorted order.
+func (selector Selector) Select(root any) []SelectedValue {
+	current := []SelectedValue{{Path: "$", Value: root}}
+
+	for _, step := range selector.steps {
+		next := make([]SelectedValue, 0, len(current))
+
+		for _, selected := range current {
+			next = append(next, step.apply(selected)...)
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+func (step selectorStep) apply(selected SelectedValue) []SelectedValue {
+	switch value := selected.Value.(type) {
+	case map[string]any:
+		if step.wildcard {
+			keys := make([]string, 0, len(value))
+
+			for key := range value {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+			out := make([]SelectedValue, 0, len(keys))
+
+			for _, key := range keys {
+				out = append(out, SelectedValue{Path: childPath(selected.Path, key), Value: value[key]})
+			}
+
+			return out
+		}
+
+		if step.isIndex {
+			return nil
+		}
+
+		child, ok := value[step.name]
+		if !ok {
+			return nil
+		}
+
+		return []SelectedValue{{Path: childPath(selected.Path, step.name), Value: child}}
+	case []any:
+		if step.wildcard {
+			out := make([]SelectedValue, 0, len(value))
+
+			for i, item := range value {
+				out = append(out, SelectedValue{Path: fmt.Sprintf("%s[%d]", selected.Path, i), Value: item})
+			}
+
+			return out
+		}
+
+		if !step.isIndex {
+			return nil
+		}
+
+		index := step.index
+
+		if index < 0 {
+			index += len(value)
+		}
+
+		if index < 0 || index >= len(value) {
+			return nil
+		}
+
+		return []SelectedValue{{Path: fmt.Sprintf("%s[%d]", selected.Path, index), Value: value[index]}}
+	default:
+		return nil
+	}
+}
+
+func childPath(parent string, name string) string {
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s[%q]", parent, name)
+		}
+	}
+
+	return parent + "." + name
+}
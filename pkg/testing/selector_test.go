@@ -0,0 +1,70 @@
+package testing_test
+
+import (
+	"encoding/json"
+	"fmt"
+	stdtesting "testing"
+
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestSelectorSelect(t *stdtesting.T) {
+	var document any
+
+	if err := json.Unmarshal([]byte(`{
+		"title": "Example",
+		"items": [{"name": "a"}, {"name": "b"}, {"name": "c"}],
+		"meta": {"og:title": "Og", "count": 3}
+	}`), &document); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		paths    []string
+		values   []any
+	}{
+		{selector: "$", paths: []string{"$"}},
+		{selector: "$.title", paths: []string{"$.title"}, values: []any{"Example"}},
+		{selector: "$.items[1].name", paths: []string{"$.items[1].name"}, values: []any{"b"}},
+		{selector: "$.items[-1].name", paths: []string{"$.items[2].name"}, values: []any{"c"}},
+		{selector: "$.items[*].name", paths: []string{"$.items[0].name", "$.items[1].name", "$.items[2].name"}, values: []any{"a", "b", "c"}},
+		{selector: "$.meta['og:title']", paths: []string{`$.meta["og:title"]`}, values: []any{"Og"}},
+		{selector: "$.meta.*", paths: []string{"$.meta.count", `$.meta["og:title"]`}, values: []any{float64(3), "Og"}},
+		{selector: "$.missing", paths: []string{}},
+		{selector: "$.items[5]", paths: []string{}},
+		{selector: "$.title.length", paths: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *stdtesting.T) {
+			selector, err := testing2.ParseSelector(test.selector)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			selected := selector.Select(document)
+			paths := make([]string, 0, len(selected))
+
+			for i, value := range selected {
+				paths = append(paths, value.Path)
+
+				if test.values != nil && value.Value != test.values[i] {
+					t.Fatalf("expected value %v at %s, got %v", test.values[i], value.Path, value.Value)
+				}
+			}
+
+			if fmt.Sprint(paths) != fmt.Sprint(test.paths) {
+				t.Fatalf("expected paths %v, got %v", test.paths, paths)
+			}
+		})
+	}
+}
+
+func TestParseSelectorRejectsInvalidSelectors(t *stdtesting.T) {
+	for _, text := range []string{"", "title", "$.", "$.items[", "$.items[x]", "$items"} {
+		if _, err := testing2.ParseSelector(text); err == nil {
+			t.Fatalf("expected error for %q", text)
+		}
+	}
+}
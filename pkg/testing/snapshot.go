@@ -0,0 +1,375 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/MontFerret/lab/v2/pkg/sources"
+)
+
+const (
+	snapshotExtension = ".snap.json"
+	// ignoredSnapshotValue replaces the values of ignored paths so that the
+	// snapshot keeps the shape of the output without its volatile values.
+	ignoredSnapshotValue = "<ignored>"
+	maxSnapshotDiffLines = 20
+)
+
+// SnapshotExpectationManifest compares the query output with a stored
+// snapshot. Path is resolved relative to the suite file and defaults to the
+// suite file name with the ".snap.json" extension. Ignore lists selectors whose
+// values are excluded from the comparison.
+type SnapshotExpectationManifest struct {
+	Path   string
+	Ignore []string
+
+	ignore []Selector
+}
+
+// UnmarshalYAML accepts either a boolean, a snapshot path or a map with path
+// and ignore, and rejects unknown fields.
+func (manifest *SnapshotExpectationManifest) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+
+	if err := unmarshal(&enabled); err == nil {
+		if !enabled {
+			return errors.New("expect.snapshot: set to true or remove it to disable snapshots")
+		}
+
+		*manifest = SnapshotExpectationManifest{}
+
+		return nil
+	}
+
+	var snapshotPath string
+
+	if err := unmarshal(&snapshotPath); err == nil {
+		*manifest = SnapshotExpectationManifest{Path: snapshotPath}
+
+		return nil
+	}
+
+	decoded := struct {
+		Path    string         `yaml:"path"`
+		Ignore  []string       `yaml:"ignore"`
+		Unknown map[string]any `yaml:",inline"`
+	}{}
+
+	if err := unmarshal(&decoded); err != nil {
+		return err
+	}
+
+	if len(decoded.Unknown) > 0 {
+		fields := make([]string, 0, len(decoded.Unknown))
+
+		for field := range decoded.Unknown {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+
+		return fmt.Errorf("expect.snapshot contains unsupported field %q", fields[0])
+	}
+
+	result := SnapshotExpectationManifest{
+		Path:   decoded.Path,
+		Ignore: decoded.Ignore,
+		ignore: make([]Selector, 0, len(decoded.Ignore)),
+	}
+
+	for _, text := range decoded.Ignore {
+		selector, err := ParseSelector(text)
+		if err != nil {
+			return fmt.Errorf("expect.snapshot: ignore: %w", err)
+		}
+
+		result.ignore = append(result.ignore, selector)
+	}
+
+	*manifest = result
+
+	return nil
+}
+
+// snapshotPath returns the snapshot location relative to the suite file.
+// Cases and matrix combinations insert their name before the extension so
+// that every execution keeps its own snapshot.
+func (suite *Suite) snapshotPath() string {
+	snapshotPath := suite.manifest.Expect.Snapshot.Path
+
+	if snapshotPath == "" {
+		name := path.Base(filepath.ToSlash(suite.file.Name))
+		snapshotPath = strings.TrimSuffix(name, path.Ext(name)) + snapshotExtension
+	}
+
+	if suite.caseName == "" {
+		return snapshotPath
+	}
+
+	ext := path.Ext(snapshotPath)
+
+	if strings.HasSuffix(snapshotPath, snapshotExtension) {
+		ext = snapshotExtension
+	}
+
+	return strings.TrimSuffix(snapshotPath, ext) + "." + snapshotName(suite.caseName) + ext
+}
+
+// snapshotName turns a case name such as "#admin[locale=en, id=1]" into a
+// file name fragment such as "admin.locale-en.id-1".
+func snapshotName(name string) string {
+	var b strings.Builder
+
+	separate := false
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			if separate && b.Len() > 0 {
+				b.WriteByte('.')
+			}
+
+			separate = false
+			b.WriteRune(r)
+		case r == '=' || r == '-':
+			if b.Len() > 0 {
+				b.WriteByte('-')
+			}
+
+			separate = false
+		default:
+			separate = true
+		}
+	}
+
+	return strings.ReplaceAll(b.String(), "--", "-")
+}
+
+func (suite *Suite) evaluateSnapshot(ctx context.Context, output any) error {
+	manifest := suite.manifest.Expect.Snapshot
+	snapshotPath := suite.snapshotPath()
+	actual := normalizeSnapshot(output, manifest.ignore)
+
+	content, err := encodeSnapshot(actual)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	var stored []byte
+
+	f, readErr := resolveRef(ctx, suite.file, snapshotPath)
+	if readErr == nil {
+		stored = f.Content
+	}
+
+	if suite.updateSnapshots {
+		if readErr == nil && bytes.Equal(stored, content) {
+			return nil
+		}
+
+		u, err := url.Parse(snapshotPath)
+		if err != nil {
+			return fmt.Errorf("parse snapshot path: %w", err)
+		}
+
+		if _, err := suite.file.Write(ctx, u, content); err != nil {
+			if errors.Is(err, sources.ErrNotWritable) {
+				return fmt.Errorf("cannot update snapshot %s: only filesystem sources can be updated", snapshotPath)
+			}
+
+			return fmt.Errorf("cannot update snapshot %s: %w", snapshotPath, err)
+		}
+
+		return nil
+	}
+
+	if readErr != nil {
+		return fmt.Errorf("cannot read snapshot %s: %w; run with --update-snapshots to create it", snapshotPath, readErr)
+	}
+
+	var expected any
+
+	if err := json.Unmarshal(stored, &expected); err != nil {
+		return fmt.Errorf("invalid snapshot %s: %w", snapshotPath, err)
+	}
+
+	diff := diffSnapshot("$", normalizeSnapshot(expected, manifest.ignore), actual, nil)
+
+	if len(diff) == 0 {
+		return nil
+	}
+
+	if len(diff) > maxSnapshotDiffLines {
+		more := len(diff) - maxSnapshotDiffLines
+		diff = append(diff[:maxSnapshotDiffLines], fmt.Sprintf("... and %d more differences", more))
+	}
+
+	return fmt.Errorf(
+		"output does not match snapshot %s; run with --update-snapshots to accept the changes:\n%s",
+		snapshotPath,
+		strings.Join(diff, "\n"),
+	)
+}
+
+// normalizeSnapshot returns a copy of the output with the values of ignored
+// paths replaced by a placeholder.
+func normalizeSnapshot(output any, ignore []Selector) any {
+	normalized := copySnapshotValue(output)
+
+	for _, selector := range ignore {
+		for _, selected := range selector.Select(normalized) {
+			replaceSelected(&normalized, "$", selected.Path)
+		}
+	}
+
+	return normalized
+}
+
+// replaceSelected walks the document to the concrete path and replaces the
+// value found there with the ignored placeholder.
+func replaceSelected(value *any, current string, target string) bool {
+	if current == target {
+		*value = ignoredSnapshotValue
+
+		return true
+	}
+
+	switch v := (*value).(type) {
+	case map[string]any:
+		for key, item := range v {
+			if p := childPath(current, key); strings.HasPrefix(target, p) && replaceSelected(&item, p, target) {
+				v[key] = item
+
+				return true
+			}
+		}
+	case []any:
+		for i := range v {
+			if p := fmt.Sprintf("%s[%d]", current, i); strings.HasPrefix(target, p) && replaceSelected(&v[i], p, target) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func copySnapshotValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for key, item := range v {
+			out[key] = copySnapshotValue(item)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			out[i] = copySnapshotValue(item)
+		}
+
+		return out
+	default:
+		return value
+	}
+}
+
+// encodeSnapshot formats the output as indented JSON with sorted keys so that
+// snapshots are stable and readable in code review.
+func encodeSnapshot(value any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// diffSnapshot lists the structural differences between the snapshot and the
+// actual output, one line per changed, added or removed path.
+func diffSnapshot(at string, expected any, actual any, diff []string) []string {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(e)+len(a))
+
+		for key := range e {
+			keys = append(keys, key)
+		}
+
+		for key := range a {
+			if _, exists := e[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			diff = diffSnapshotChild(childPath(at, key), e, a, key, diff)
+		}
+
+		return diff
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+
+		for i := 0; i < max(len(e), len(a)); i++ {
+			p := fmt.Sprintf("%s[%d]", at, i)
+
+			switch {
+			case i >= len(a):
+				diff = append(diff, fmt.Sprintf("%s: removed, was %s", p, formatValue(e[i])))
+			case i >= len(e):
+				diff = append(diff, fmt.Sprintf("%s: added %s", p, formatValue(a[i])))
+			default:
+				diff = diffSnapshot(p, e[i], a[i], diff)
+			}
+		}
+
+		return diff
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		diff = append(diff, fmt.Sprintf("%s: changed from %s to %s", at, formatValue(expected), formatValue(actual)))
+	}
+
+	return diff
+}
+
+func diffSnapshotChild(at string, expected map[string]any, actual map[string]any, key string, diff []string) []string {
+	e, inExpected := expected[key]
+	a, inActual := actual[key]
+
+	switch {
+	case !inActual:
+		return append(diff, fmt.Sprintf("%s: removed, was %s", at, formatValue(e)))
+	case !inExpected:
+		return append(diff, fmt.Sprintf("%s: added %s", at, formatValue(a)))
+	default:
+		return diffSnapshot(at, e, a, diff)
+	}
+}
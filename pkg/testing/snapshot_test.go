@@ -0,0 +1,202 @@
+package testing_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	stdtesting "testing"
+
+	ferretsource "github.com/MontFerret/ferret/v2/pkg/source"
+
+	labruntime "github.com/MontFerret/lab/v2/pkg/runtime"
+	"github.com/MontFerret/lab/v2/pkg/sources"
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestSuiteExpectSnapshot(t *stdtesting.T) {
+	dir := t.TempDir()
+	suitePath := filepath.Join(dir, "page.yaml")
+	snapshotPath := filepath.Join(dir, "page.snap.json")
+	output := `{"title": "Example", "items": [1, 2], "fetchedAt": "2026-01-01T00:00:00Z"}`
+
+	writeSnapshotSuite(t, suitePath, `
+query:
+  text: RETURN 1
+expect:
+  snapshot:
+    ignore: [$.fetchedAt]
+`)
+
+	run := func(update bool) error {
+		return runSnapshotSuite(t, suitePath, update, func() string { return output })
+	}
+
+	err := run(false)
+	if err == nil || !strings.Contains(err.Error(), "cannot read snapshot page.snap.json") ||
+		!strings.Contains(err.Error(), "--update-snapshots") {
+		t.Fatalf("expected missing snapshot error, got %v", err)
+	}
+
+	if err := run(true); err != nil {
+		t.Fatalf("expected snapshot to be created, got %v", err)
+	}
+
+	content, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("expected snapshot file, got %v", err)
+	}
+
+	expected := "{\n  \"fetchedAt\": \"<ignored>\",\n  \"items\": [\n    1,\n    2\n  ],\n  \"title\": \"Example\"\n}\n"
+
+	if string(content) != expected {
+		t.Fatalf("unexpected snapshot content:\n%s", content)
+	}
+
+	output = `{"title": "Example", "items": [1, 2], "fetchedAt": "2026-02-02T00:00:00Z"}`
+
+	if err := run(false); err != nil {
+		t.Fatalf("expected ignored fields not to affect the comparison, got %v", err)
+	}
+
+	output = `{"title": "Changed", "items": [1], "fetchedAt": "now", "author": "me"}`
+
+	err = run(false)
+	if err == nil {
+		t.Fatal("expected snapshot mismatch")
+	}
+
+	expectedDiff := strings.Join([]string{
+		`$.author: added "me"`,
+		`$.items[1]: removed, was 2`,
+		`$.title: changed from "Example" to "Changed"`,
+	}, "\n")
+
+	if !strings.HasSuffix(err.Error(), ":\n"+expectedDiff) ||
+		!strings.HasPrefix(err.Error(), "expect.snapshot: output does not match snapshot page.snap.json") {
+		t.Fatalf("unexpected mismatch error:\n%v", err)
+	}
+
+	if err := run(true); err != nil {
+		t.Fatalf("expected snapshot to be updated, got %v", err)
+	}
+
+	if err := run(false); err != nil {
+		t.Fatalf("expected updated snapshot to match, got %v", err)
+	}
+}
+
+func TestSuiteExpectSnapshotPerCase(t *stdtesting.T) {
+	dir := t.TempDir()
+	suitePath := filepath.Join(dir, "pages.yaml")
+
+	writeSnapshotSuite(t, suitePath, `
+query:
+  text: RETURN 1
+expect:
+  snapshot: snapshots/pages.json
+cases:
+  - name: home
+  - name: about
+matrix:
+  locale: [en, de]
+`)
+
+	if err := runSnapshotSuite(t, suitePath, true, func() string { return `{"ok": true}` }); err != nil {
+		t.Fatalf("expected snapshots to be created, got %v", err)
+	}
+
+	for _, name := range []string{"home.locale-en", "home.locale-de", "about.locale-en", "about.locale-de"} {
+		if _, err := os.Stat(filepath.Join(dir, "snapshots", "pages."+name+".json")); err != nil {
+			t.Fatalf("expected snapshot for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestSuiteExpectSnapshotUpdateRequiresWritableSource(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Source:  sources.NewNoop(),
+			Name:    "suite.yaml",
+			Content: []byte("query:\n  text: RETURN 1\nexpect:\n  snapshot: true\n"),
+		},
+		UpdateSnapshots: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = testCase.Run(context.Background(), snapshotRuntime(func() string { return "1" }), testing2.NewParams())
+
+	expected := "expect.snapshot: cannot update snapshot suite.snap.json: only filesystem sources can be updated"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestSuiteExpectSnapshotValidation(t *stdtesting.T) {
+	tests := map[string]string{
+		"snapshot: false":                    "expect.snapshot: set to true or remove it to disable snapshots",
+		"snapshot:\n    paths: a.json":       `expect.snapshot contains unsupported field "paths"`,
+		"snapshot:\n    ignore: [fetchedAt]": `expect.snapshot: ignore: selector "fetchedAt" must start with $`,
+		"error: {}\n  snapshot: true":        "expect.error cannot be combined with expect.snapshot",
+	}
+
+	for expect, expected := range tests {
+		_, err := testing2.New(testing2.Options{
+			File: sources.File{
+				Name:    "suite.yaml",
+				Content: []byte("query:\n  text: RETURN 1\nexpect:\n  " + expect + "\n"),
+			},
+		})
+
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%q: expected error containing %q, got %v", expect, expected, err)
+		}
+	}
+}
+
+func writeSnapshotSuite(t *stdtesting.T, name string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(strings.TrimSpace(content)+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write suite: %v", err)
+	}
+}
+
+func runSnapshotSuite(t *stdtesting.T, name string, update bool, output func() string) error {
+	t.Helper()
+
+	u, err := url.Parse(name)
+	if err != nil {
+		t.Fatalf("failed to parse path: %v", err)
+	}
+
+	src, err := sources.NewFileSystem(u)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read suite: %v", err)
+	}
+
+	testCase, err := testing2.New(testing2.Options{
+		File:            sources.File{Source: src, Name: name, Content: content},
+		UpdateSnapshots: update,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return testCase.Run(context.Background(), snapshotRuntime(output), testing2.NewParams())
+}
+
+func snapshotRuntime(output func() string) labruntime.Runtime {
+	return labruntime.AsFunc(func(_ context.Context, _ *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		return []byte(output()), nil
+	})
+}
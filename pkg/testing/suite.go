@@ -3,12 +3,14 @@ package testing
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v2"
-
 	"github.com/MontFerret/lab/v2/pkg/runtime"
 	"github.com/MontFerret/lab/v2/pkg/sources"
 
@@ -17,13 +19,27 @@ import (
 
 type (
 	Suite struct {
-		file     sources.File
-		timeout  time.Duration
-		manifest SuiteManifest
+		file           sources.File
+		timeout        time.Duration
+		manifest       SuiteManifest
+		runtimeVersion *VersionConstraint
+		// caseName is the suffix a case or matrix combination appends to the
+		// file name, e.g. "#admin[locale=en]".
+		caseName        string
+		updateSnapshots bool
+	}
+
+	// NamedCase is a case of a multi-case suite together with the name it is
+	// reported under.
+	NamedCase struct {
+		Name string
+		Case Case
 	}
 
 	DataContext struct {
-		Query DataContextValues `json:"query"`
+		BeforeAll DataContextValues `json:"beforeAll,omitempty"`
+		Setup     DataContextValues `json:"setup,omitempty"`
+		Query     DataContextValues `json:"query"`
 	}
 
 	DataContextValues struct {
@@ -33,10 +49,9 @@ type (
 )
 
 func NewSuite(opts Options) (*Suite, error) {
-	manifest := SuiteManifest{}
-
-	if err := yaml.Unmarshal(opts.File.Content, &manifest); err != nil {
-		return nil, fmt.Errorf("failed to parse file: %w", err)
+	manifest, err := decodeManifest(opts.File)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := manifest.validate(); err != nil {
@@ -49,18 +64,195 @@ func NewSuite(opts Options) (*Suite, error) {
 		timeout = time.Duration(manifest.Timeout) * time.Second
 	}
 
-	return &Suite{
-		file:     opts.File,
-		timeout:  timeout,
-		manifest: manifest,
-	}, nil
+	suite := &Suite{
+		file:            opts.File,
+		timeout:         timeout,
+		manifest:        manifest,
+		updateSnapshots: opts.UpdateSnapshots,
+	}
+
+	if manifest.RuntimeVersion != "" {
+		constraint, err := ParseVersionConstraint(manifest.RuntimeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("runtimeVersion: %w", err)
+		}
+
+		suite.runtimeVersion = &constraint
+	}
+
+	return suite, nil
+}
+
+// Cases returns the executions declared by the suite manifest: one per case,
+// reported as "<file>#<case name>", times one per matrix combination, reported
+// with the combination appended, e.g. "<file>#<case name>[locale=en]".
+// It returns nil for a suite without cases and matrix.
+func (suite *Suite) Cases() []NamedCase {
+	combinations := suite.manifest.Matrix.combinations()
+
+	if len(suite.manifest.Cases) == 0 && combinations == nil {
+		return nil
+	}
+
+	type base struct {
+		name     string
+		manifest SuiteManifest
+	}
+
+	bases := []base{{name: suite.file.Name, manifest: suite.manifest}}
+
+	if len(suite.manifest.Cases) > 0 {
+		bases = make([]base, 0, len(suite.manifest.Cases))
+
+		for _, c := range suite.manifest.Cases {
+			bases = append(bases, base{
+				name:     suite.file.Name + "#" + strings.TrimSpace(c.Name),
+				manifest: suite.manifest.forCase(c),
+			})
+		}
+	}
+
+	cases := make([]NamedCase, 0, len(bases)*max(len(combinations), 1))
+
+	for _, b := range bases {
+		if combinations == nil {
+			cases = append(cases, suite.namedCase(b.name, b.manifest))
+
+			continue
+		}
+
+		for _, combination := range combinations {
+			cases = append(cases, suite.namedCase(b.name+combination.label(), b.manifest.forCombination(combination)))
+		}
+	}
+
+	return cases
+}
+
+func (suite *Suite) namedCase(name string, manifest SuiteManifest) NamedCase {
+	return NamedCase{
+		Name: name,
+		Case: &Suite{
+			file:            suite.file,
+			timeout:         suite.timeout,
+			manifest:        manifest,
+			runtimeVersion:  suite.runtimeVersion,
+			caseName:        strings.TrimPrefix(name, suite.file.Name),
+			updateSnapshots: suite.updateSnapshots,
+		},
+	}
+}
+
+// Tags returns the tags declared by the suite manifest.
+func (suite *Suite) Tags() []string {
+	return suite.manifest.Tags
+}
+
+// SkipReason returns the reason declared by the manifest 'skip' field, or an
+// empty string when the suite is enabled.
+func (suite *Suite) SkipReason() string {
+	return strings.TrimSpace(suite.manifest.Skip)
+}
+
+// DependsOn returns the names of the files the suite depends on, resolved
+// relative to the suite file.
+func (suite *Suite) DependsOn() []string {
+	if len(suite.manifest.DependsOn) == 0 {
+		return nil
+	}
+
+	deps := make([]string, 0, len(suite.manifest.DependsOn))
+
+	for _, dep := range suite.manifest.DependsOn {
+		deps = append(deps, siblingName(suite.file.Name, strings.TrimSpace(dep)))
+	}
+
+	return deps
+}
+
+// siblingName resolves the reference relative to the file name the way the
+// source of the file names its files: URLs of HTTP sources are resolved as
+// URLs, local paths with the separator of the OS and paths of git sources,
+// which always use slashes, as slash paths.
+func siblingName(name string, ref string) string {
+	if u, err := url.Parse(name); err == nil && len(u.Scheme) > 1 {
+		if r, err := url.Parse(ref); err == nil {
+			return u.ResolveReference(r).String()
+		}
+	}
+
+	if (filepath.Separator != '/' && strings.ContainsRune(name, filepath.Separator)) || filepath.IsAbs(name) {
+		return filepath.Join(filepath.Dir(name), filepath.FromSlash(ref))
+	}
+
+	return path.Join(path.Dir(name), ref)
+}
+
+// RuntimeVersionConstraint returns the runtime version constraint declared by
+// the manifest, if any.
+func (suite *Suite) RuntimeVersionConstraint() (VersionConstraint, bool) {
+	if suite.runtimeVersion == nil {
+		return VersionConstraint{}, false
+	}
+
+	return *suite.runtimeVersion, true
 }
 
 func (suite *Suite) Run(ctx context.Context, rt runtime.Runtime, params Params) error {
-	ctx, cancel := context.WithTimeout(ctx, suite.timeout)
+	if cases := suite.Cases(); cases != nil {
+		for _, c := range cases {
+			if err := c.Case.Run(ctx, rt, params.Clone()); err != nil {
+				return fmt.Errorf("%s: %w", c.Name, err)
+			}
+		}
+
+		return nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, suite.timeout)
 	defer cancel()
 
-	query, err := suite.resolveScript(ctx, "query", suite.manifest.Query)
+	err := suite.run(runCtx, rt, params)
+
+	if suite.manifest.Teardown != nil {
+		// teardown runs even after a failure or timeout, with its own deadline
+		teardownCtx, cancelTeardown := context.WithTimeout(context.WithoutCancel(ctx), suite.timeout)
+		defer cancelTeardown()
+
+		if teardownErr := suite.teardown(teardownCtx, rt, params); teardownErr != nil {
+			return errors.Join(err, teardownErr)
+		}
+	}
+
+	return err
+}
+
+func (suite *Suite) run(ctx context.Context, rt runtime.Runtime, params Params) error {
+	if suite.manifest.Setup != nil {
+		setup, err := resolveScript(ctx, suite.file, "setup", *suite.manifest.Setup)
+		if err != nil {
+			return fmt.Errorf("resolve setup script: %w", err)
+		}
+
+		setupParams := suite.manifest.Setup.runtimeParams(params.Clone())
+
+		out, err := rt.Run(ctx, setup, setupParams)
+		if err != nil {
+			return fmt.Errorf("failed to execute setup script: %w", err)
+		}
+
+		outVal, err := deserializeOutput(out)
+		if err != nil {
+			return fmt.Errorf("deserialize setup output: %w", err)
+		}
+
+		params.SetDataValue("setup", DataContextValues{
+			Result: outVal,
+			Params: setupParams,
+		})
+	}
+
+	query, err := resolveScript(ctx, suite.file, "query", suite.manifest.Query)
 	if err != nil {
 		return fmt.Errorf("resolve query script: %w", err)
 	}
@@ -71,9 +263,13 @@ func (suite *Suite) Run(ctx context.Context, rt runtime.Runtime, params Params)
 		return expectedError.evaluate(err)
 	}
 
-	assertion, err := suite.resolveScript(ctx, "assert", *suite.manifest.Assert)
-	if err != nil {
-		return fmt.Errorf("resolve assertion script: %w", err)
+	var assertion *source.Source
+
+	if suite.manifest.Assert != nil {
+		assertion, err = resolveScript(ctx, suite.file, "assert", *suite.manifest.Assert)
+		if err != nil {
+			return fmt.Errorf("resolve assertion script: %w", err)
+		}
 	}
 
 	queryParams := suite.manifest.Query.runtimeParams(params.Clone())
@@ -83,44 +279,95 @@ func (suite *Suite) Run(ctx context.Context, rt runtime.Runtime, params Params)
 		return fmt.Errorf("failed to execute query script: %w", err)
 	}
 
-	outVal, err := suite.deserializeQueryOutput(out)
+	outVal, err := deserializeOutput(out)
 	if err != nil {
 		return fmt.Errorf("deserialize query output: %w", err)
 	}
 
-	params.SetSystemValue("data", DataContext{
-		Query: DataContextValues{
-			Result: outVal,
-			Params: queryParams,
-		},
+	params.SetDataValue("query", DataContextValues{
+		Result: outVal,
+		Params: queryParams,
 	})
 
+	if expectations := suite.manifest.Expect.Result; len(expectations) > 0 {
+		if err := evaluateResultExpectations(expectations, outVal); err != nil {
+			return fmt.Errorf("expect.result: %w", err)
+		}
+	}
+
+	if suite.manifest.Expect.Schema != nil {
+		if err := suite.evaluateSchema(ctx, outVal); err != nil {
+			return fmt.Errorf("expect.schema: %w", err)
+		}
+	}
+
+	if suite.manifest.Expect.Snapshot != nil {
+		if err := suite.evaluateSnapshot(ctx, outVal); err != nil {
+			return fmt.Errorf("expect.snapshot: %w", err)
+		}
+	}
+
+	if assertion == nil {
+		return nil
+	}
+
 	_, err = rt.Run(ctx, assertion, suite.manifest.Assert.runtimeParams(params))
 
 	return err
 }
 
-func (suite *Suite) resolveScript(ctx context.Context, scriptType string, manifest ScriptManifest) (*source.Source, error) {
+// teardown runs the teardown script with the data of the phases that
+// completed.
+func (suite *Suite) teardown(ctx context.Context, rt runtime.Runtime, params Params) error {
+	teardown, err := resolveScript(ctx, suite.file, "teardown", *suite.manifest.Teardown)
+	if err != nil {
+		return fmt.Errorf("resolve teardown script: %w", err)
+	}
+
+	if _, err := rt.Run(ctx, teardown, suite.manifest.Teardown.runtimeParams(params.Clone())); err != nil {
+		return fmt.Errorf("failed to execute teardown script: %w", err)
+	}
+
+	return nil
+}
+
+func resolveScript(ctx context.Context, file sources.File, scriptType string, manifest ScriptManifest) (*source.Source, error) {
 	if manifest.Text != "" {
-		return source.New(fmt.Sprintf("%s -> %s", suite.file.Name, scriptType), manifest.Text), nil
+		return source.New(fmt.Sprintf("%s -> %s", file.Name, scriptType), manifest.Text), nil
+	}
+
+	f, err := resolveRef(ctx, file, manifest.Ref)
+	if err != nil {
+		return nil, err
 	}
 
-	u, err := url.Parse(manifest.Ref)
+	return source.New(f.Name, string(f.Content)), nil
+}
+
+// resolveRef reads a file referenced relative to another file through its source.
+func resolveRef(ctx context.Context, file sources.File, ref string) (sources.File, error) {
+	u, err := url.Parse(ref)
 	if err != nil {
-		return nil, fmt.Errorf("parse 'ref': %w", err)
+		return sources.File{}, fmt.Errorf("parse 'ref': %w", err)
 	}
 
-	onNext, onError := suite.file.Resolve(ctx, u)
+	onNext, onError := file.Resolve(ctx, u)
 
 	select {
-	case e := <-onError:
-		return nil, fmt.Errorf("resolve 'ref': %w", e)
-	case f := <-onNext:
-		return source.New(f.Name, string(f.Content)), nil
+	case e, ok := <-onError:
+		if ok {
+			return sources.File{}, fmt.Errorf("resolve 'ref': %w", e)
+		}
+	case f, ok := <-onNext:
+		if ok {
+			return f, nil
+		}
 	}
+
+	return sources.File{}, fmt.Errorf("resolve 'ref': %s not found", ref)
 }
 
-func (suite *Suite) deserializeQueryOutput(values []byte) (any, error) {
+func deserializeOutput(values []byte) (any, error) {
 	if len(values) == 0 {
 		return nil, nil
 	}
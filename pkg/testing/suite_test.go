@@ -3,6 +3,7 @@ package testing_test
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"strings"
 	stdtesting "testing"
 	"time"
@@ -151,6 +152,67 @@ func TestSuiteExpectedError(t *stdtesting.T) {
 			expect:  "error:\n    contains: expected Array",
 			wantErr: "expected query to fail, but it completed successfully",
 		},
+		{
+			name:       "equal error",
+			expect:     "error:\n    equals: element not found",
+			runtimeErr: errors.New("element not found\n"),
+		},
+		{
+			name:       "non-equal error",
+			expect:     "error:\n    equals: element not found",
+			runtimeErr: errors.New("element not found: #login"),
+			wantErr:    `expected error equal to "element not found", got: element not found: #login`,
+		},
+		{
+			name:       "matching pattern",
+			expect:     "error:\n    matches: \"^element .+ not found$\"",
+			runtimeErr: errors.New("element #login not found"),
+		},
+		{
+			name:       "non-matching pattern",
+			expect:     "error:\n    matches: \"^element .+ not found$\"",
+			runtimeErr: errors.New("timeout"),
+			wantErr:    `expected error matching "^element .+ not found$", got: timeout`,
+		},
+		{
+			name:       "excluded text",
+			expect:     "error:\n    contains: not found\n    not_contains: panic",
+			runtimeErr: errors.New("panic: element not found"),
+			wantErr:    `expected error not containing "panic", got: panic: element not found`,
+		},
+		{
+			name:       "compile error position",
+			expect:     "error:\n    kind: compile\n    line: 2\n    column: 8",
+			runtimeErr: errors.New("SyntaxError: syntax error at 2:8: unexpected token 'RETRUN'"),
+		},
+		{
+			name:       "parser mismatch",
+			expect:     "error:\n    kind: compile\n    line: 1\n    column: 7",
+			runtimeErr: errors.New("line 1:7 mismatched input 'RETRUN' expecting {<EOF>, 'RETURN'}"),
+		},
+		{
+			name:       "value parse failure is a runtime error",
+			expect:     "error:\n    kind: runtime",
+			runtimeErr: errors.New(`failed to parse "2024-13-01" as date: parse error at line 1, column 6`),
+		},
+		{
+			name:       "compile error expected but runtime error reported",
+			expect:     "error:\n    kind: compile",
+			runtimeErr: errors.New("element not found at line 4, column 3"),
+			wantErr:    "expected a compile error, got a runtime error: element not found at line 4, column 3",
+		},
+		{
+			name:       "runtime error on another line",
+			expect:     "error:\n    kind: runtime\n    line: 3",
+			runtimeErr: errors.New("element not found at line 4, column 3"),
+			wantErr:    "expected error at line 3, got line 4: element not found at line 4, column 3",
+		},
+		{
+			name:       "position expected but not reported",
+			expect:     "error:\n    line: 1",
+			runtimeErr: errors.New("element not found"),
+			wantErr:    "expected error at line 1, but the error reports no position: element not found",
+		},
 	}
 
 	for _, test := range tests {
@@ -299,6 +361,39 @@ expect:
 `,
 			wantErr: `expect.error contains unsupported field "contians"`,
 		},
+		{
+			name: "unknown error kind",
+			content: `
+query:
+  text: RETURN 1
+expect:
+  error:
+    kind: syntax
+`,
+			wantErr: `expect.error: kind must be "compile" or "runtime", got "syntax"`,
+		},
+		{
+			name: "column without line",
+			content: `
+query:
+  text: RETURN 1
+expect:
+  error:
+    column: 3
+`,
+			wantErr: "expect.error: column requires line",
+		},
+		{
+			name: "invalid error pattern",
+			content: `
+query:
+  text: RETURN 1
+expect:
+  error:
+    matches: "("
+`,
+			wantErr: "expect.error: invalid matches pattern",
+		},
 	}
 
 	for _, test := range tests {
@@ -344,3 +439,473 @@ expect:
 		t.Fatal("expected a constructed suite")
 	}
 }
+
+func TestSuiteTags(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name: "suite.yaml",
+			Content: []byte(`
+tags: [smoke, api]
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	suite, ok := testCase.(*testing2.Suite)
+	if !ok {
+		t.Fatalf("expected suite, got %T", testCase)
+	}
+
+	if tags := suite.Tags(); len(tags) != 2 || tags[0] != "smoke" || tags[1] != "api" {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+
+	_, err = testing2.New(testing2.Options{
+		File: sources.File{
+			Name: "suite.yaml",
+			Content: []byte(`
+tags: [smoke, ""]
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`),
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "tags: tag cannot be empty") {
+		t.Fatalf("expected empty tag error, got %v", err)
+	}
+}
+
+func TestSuiteSkipAndRuntimeVersion(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name: "suite.yaml",
+			Content: []byte(`
+skip: " waiting for upstream fix "
+runtimeVersion: ">=2.1.0, <3"
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	suite := testCase.(*testing2.Suite)
+
+	if reason := suite.SkipReason(); reason != "waiting for upstream fix" {
+		t.Fatalf("unexpected skip reason %q", reason)
+	}
+
+	constraint, ok := suite.RuntimeVersionConstraint()
+	if !ok || constraint.String() != ">=2.1.0, <3" {
+		t.Fatalf("unexpected runtime version constraint %q", constraint)
+	}
+
+	_, err = testing2.New(testing2.Options{
+		File: sources.File{
+			Name: "suite.yaml",
+			Content: []byte(`
+runtimeVersion: ">=two"
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+`),
+		},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "runtimeVersion: invalid constraint") {
+		t.Fatalf("expected runtime version error, got %v", err)
+	}
+}
+
+func TestSuiteCasesShareTopLevelScripts(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name: "page.yaml",
+			Content: []byte(`
+tags: [page]
+query:
+  text: RETURN @selector
+  params:
+    selector: "h1"
+assert:
+  text: RETURN true
+cases:
+  - name: title
+  - name: footer
+    tags: [slow]
+    params:
+      selector: "footer"
+  - name: missing
+    query:
+      text: RETURN NONE()
+    expect:
+      error:
+        contains: "missing"
+`),
+		},
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cases := testCase.(*testing2.Suite).Cases()
+
+	if len(cases) != 3 {
+		t.Fatalf("expected 3 cases, got %d", len(cases))
+	}
+
+	names := []string{"page.yaml#title", "page.yaml#footer", "page.yaml#missing"}
+
+	for i, name := range names {
+		if cases[i].Name != name {
+			t.Fatalf("expected case %d to be named %q, got %q", i, name, cases[i].Name)
+		}
+	}
+
+	if tags := cases[1].Case.(*testing2.Suite).Tags(); len(tags) != 2 || tags[0] != "page" || tags[1] != "slow" {
+		t.Fatalf("expected case tags to extend suite tags, got %v", tags)
+	}
+
+	var selectors []any
+	var calls int
+
+	rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+		calls++
+
+		if query.Content() == "RETURN NONE()" {
+			return nil, errors.New("missing element")
+		}
+
+		if query.Content() == "RETURN @selector" {
+			selectors = append(selectors, params["selector"])
+		}
+
+		return []byte(`true`), nil
+	})
+
+	for _, c := range cases {
+		if err := c.Case.Run(context.Background(), rt, testing2.NewParams()); err != nil {
+			t.Fatalf("expected %s to pass, got %v", c.Name, err)
+		}
+	}
+
+	if len(selectors) != 2 || selectors[0] != "h1" || selectors[1] != "footer" {
+		t.Fatalf("expected case params to override shared params, got %v", selectors)
+	}
+
+	if calls != 5 {
+		t.Fatalf("expected 5 runtime calls, got %d", calls)
+	}
+}
+
+func TestSuiteCasesValidation(t *stdtesting.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{
+			name: "missing name",
+			manifest: `
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+cases:
+  - params: {}
+`,
+			expected: "cases[0]: name cannot be empty",
+		},
+		{
+			name: "duplicate name",
+			manifest: `
+query:
+  text: RETURN 1
+assert:
+  text: RETURN true
+cases:
+  - name: a
+  - name: a
+`,
+			expected: `cases[1]: duplicate case name "a"`,
+		},
+		{
+			name: "missing shared query",
+			manifest: `
+cases:
+  - name: a
+    assert:
+      text: RETURN true
+`,
+			expected: `cases[0] "a": query: ref or text must have value`,
+		},
+		{
+			name: "assert combined with expected error",
+			manifest: `
+query:
+  text: RETURN 1
+cases:
+  - name: a
+    assert:
+      text: RETURN true
+    expect:
+      error: {}
+`,
+			expected: `cases[0] "a": expect.error cannot be combined with assert`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			_, err := testing2.New(testing2.Options{
+				File: sources.File{Name: "suite.yaml", Content: []byte(test.manifest)},
+			})
+
+			if err == nil || err.Error() != test.expected {
+				t.Fatalf("expected error %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestSuiteSetupAndTeardown(t *stdtesting.T) {
+	const content = `
+setup:
+  text: SETUP
+  params:
+    user: admin
+teardown:
+  text: TEARDOWN
+query:
+  text: QUERY
+assert:
+  text: ASSERT
+`
+
+	tests := []struct {
+		name     string
+		fail     string
+		calls    []string
+		expected []string
+	}{
+		{
+			name:  "all phases pass",
+			calls: []string{"SETUP", "QUERY", "ASSERT", "TEARDOWN"},
+		},
+		{
+			name:     "setup fails",
+			fail:     "SETUP",
+			calls:    []string{"SETUP", "TEARDOWN"},
+			expected: []string{"failed to execute setup script: SETUP failed"},
+		},
+		{
+			name:     "query fails",
+			fail:     "QUERY",
+			calls:    []string{"SETUP", "QUERY", "TEARDOWN"},
+			expected: []string{"failed to execute query script: QUERY failed"},
+		},
+		{
+			name:     "assertion fails",
+			fail:     "ASSERT",
+			calls:    []string{"SETUP", "QUERY", "ASSERT", "TEARDOWN"},
+			expected: []string{"ASSERT failed"},
+		},
+		{
+			name:     "teardown fails",
+			fail:     "TEARDOWN",
+			calls:    []string{"SETUP", "QUERY", "ASSERT", "TEARDOWN"},
+			expected: []string{"failed to execute teardown script: TEARDOWN failed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *stdtesting.T) {
+			testCase, err := testing2.New(testing2.Options{
+				File:    sources.File{Name: "suite.yaml", Content: []byte(content)},
+				Timeout: time.Second,
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			calls := make([]string, 0)
+			setupResults := make(map[string]any)
+
+			rt := labruntime.AsFunc(func(_ context.Context, query *ferretsource.Source, params map[string]any) ([]byte, error) {
+				script := query.Content()
+				calls = append(calls, script)
+
+				lab, _ := params["lab"].(map[string]any)
+				data, _ := lab["data"].(map[string]any)
+				setup, _ := data["setup"].(map[string]any)
+				setupResults[script] = setup["result"]
+
+				if script == "SETUP" {
+					if params["user"] != "admin" {
+						t.Fatalf("expected setup params, got %v", params)
+					}
+
+					if _, exists := data["setup"]; exists {
+						t.Fatal("expected no setup data before setup completes")
+					}
+				}
+
+				if script == test.fail {
+					return nil, errors.New(script + " failed")
+				}
+
+				return []byte(`{"token":"secret"}`), nil
+			})
+
+			err = testCase.Run(context.Background(), rt, testing2.NewParams())
+
+			if strings.Join(calls, ",") != strings.Join(test.calls, ",") {
+				t.Fatalf("expected calls %v, got %v", test.calls, calls)
+			}
+
+			for _, script := range []string{"QUERY", "ASSERT"} {
+				if result, called := setupResults[script]; called {
+					if token, _ := result.(map[string]any)["token"].(string); token != "secret" {
+						t.Fatalf("expected %s to see the setup result, got %v", script, result)
+					}
+				}
+			}
+
+			if test.fail != "SETUP" {
+				if result, _ := setupResults["TEARDOWN"].(map[string]any); result["token"] != "secret" {
+					t.Fatalf("expected teardown to see the setup result, got %v", setupResults["TEARDOWN"])
+				}
+			}
+
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != strings.Join(test.expected, "\n") {
+				t.Fatalf("expected error %q, got %v", strings.Join(test.expected, "\n"), err)
+			}
+		})
+	}
+}
+
+func TestSuiteTeardownRunsAfterTimeout(t *stdtesting.T) {
+	testCase, err := testing2.New(testing2.Options{
+		File: sources.File{
+			Name:    "suite.yaml",
+			Content: []byte("teardown:\n  text: TEARDOWN\nquery:\n  text: QUERY\nassert:\n  text: ASSERT\n"),
+		},
+		Timeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var tornDown bool
+
+	rt := labruntime.AsFunc(func(ctx context.Context, query *ferretsource.Source, _ map[string]any) ([]byte, error) {
+		if query.Content() == "TEARDOWN" {
+			tornDown = ctx.Err() == nil
+
+			return nil, errors.New("cleanup failed")
+		}
+
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	})
+
+	err = testCase.Run(context.Background(), rt, testing2.NewParams())
+
+	if !tornDown {
+		t.Fatal("expected teardown to run with a live context after the query timed out")
+	}
+
+	expected := "failed to execute query script: context deadline exceeded\nfailed to execute teardown script: cleanup failed"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestSuiteSetupAndTeardownValidation(t *stdtesting.T) {
+	for field, expected := range map[string]string{
+		"setup":    "setup: ref or text must have value",
+		"teardown": "teardown: ref or text must have value",
+	} {
+		_, err := testing2.New(testing2.Options{
+			File: sources.File{
+				Name:    "suite.yaml",
+				Content: []byte(field + ": {}\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+			},
+		})
+
+		if err == nil || err.Error() != expected {
+			t.Fatalf("expected %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestSuiteDependsOn(t *stdtesting.T) {
+	suite, err := testing2.NewSuite(testing2.Options{
+		File: sources.File{
+			Name:    "flows/checkout/purchase.yaml",
+			Content: []byte("dependsOn:\n  - ./login.yaml\n  - ../account.yaml\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	deps := strings.Join(suite.DependsOn(), ", ")
+
+	if deps != "flows/checkout/login.yaml, flows/account.yaml" {
+		t.Fatalf("expected dependencies relative to the suite, got %s", deps)
+	}
+
+	for name, expected := range map[string]string{
+		"https://example.com/flows/checkout/purchase.yaml": "https://example.com/flows/checkout/login.yaml, https://example.com/flows/account.yaml",
+		"/tests/flows/checkout/purchase.yaml":              filepath.Join("/tests/flows/checkout", "login.yaml") + ", " + filepath.Join("/tests/flows", "account.yaml"),
+	} {
+		suite, err := testing2.NewSuite(testing2.Options{
+			File: sources.File{
+				Name:    name,
+				Content: []byte("dependsOn:\n  - ./login.yaml\n  - ../account.yaml\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+
+		if deps := strings.Join(suite.DependsOn(), ", "); deps != expected {
+			t.Fatalf("%s: expected %s, got %s", name, expected, deps)
+		}
+	}
+
+	_, err = testing2.NewSuite(testing2.Options{
+		File: sources.File{
+			Name:    "purchase.yaml",
+			Content: []byte("dependsOn: ['']\nquery:\n  text: RETURN 1\nassert:\n  text: RETURN true\n"),
+		},
+	})
+
+	if err == nil || err.Error() != "dependsOn: dependency cannot be empty" {
+		t.Fatalf("expected empty dependency error, got %v", err)
+	}
+}
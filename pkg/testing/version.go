@@ -0,0 +1,218 @@
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// VersionConstraint is a comma-separated list of version comparisons that
+	// must all hold, e.g. ">=2.0.0, <3".
+	VersionConstraint struct {
+		text        string
+		comparisons []versionComparison
+	}
+
+	versionComparison struct {
+		operator string
+		version  version
+	}
+
+	version struct {
+		numbers    []int
+		prerelease string
+	}
+)
+
+var (
+	versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+	versionPattern   = regexp.MustCompile(`v?(\d+(?:\.\d+)*)(-[0-9A-Za-z.-]+)?`)
+)
+
+// ParseVersionConstraint parses a constraint such as ">=2.1.0, <3".
+// A comparison without an operator requires an exact match.
+func ParseVersionConstraint(text string) (VersionConstraint, error) {
+	constraint := VersionConstraint{text: strings.TrimSpace(text)}
+
+	if constraint.text == "" {
+		return constraint, errors.New("constraint cannot be empty")
+	}
+
+	for _, part := range strings.Split(constraint.text, ",") {
+		part = strings.TrimSpace(part)
+		operator := "="
+
+		for _, candidate := range versionOperators {
+			if strings.HasPrefix(part, candidate) {
+				operator = candidate
+				part = strings.TrimSpace(strings.TrimPrefix(part, candidate))
+
+				break
+			}
+		}
+
+		v, err := parseVersion(part)
+		if err != nil {
+			return constraint, fmt.Errorf("invalid constraint %q: %w", constraint.text, err)
+		}
+
+		constraint.comparisons = append(constraint.comparisons, versionComparison{
+			operator: operator,
+			version:  v,
+		})
+	}
+
+	return constraint, nil
+}
+
+// String returns the constraint as declared.
+func (constraint VersionConstraint) String() string {
+	return constraint.text
+}
+
+// Check reports whether the version satisfies every comparison. The version
+// may be embedded in a longer string, as printed by "ferret version".
+func (constraint VersionConstraint) Check(value string) (bool, error) {
+	match := versionPattern.FindString(value)
+
+	if match == "" {
+		return false, fmt.Errorf("cannot determine version from %q", value)
+	}
+
+	v, err := parseVersion(match)
+	if err != nil {
+		return false, err
+	}
+
+	for _, comparison := range constraint.comparisons {
+		if !comparison.matches(v) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (comparison versionComparison) matches(v version) bool {
+	order := v.compare(comparison.version)
+
+	switch comparison.operator {
+	case ">=":
+		return order >= 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case "<":
+		return order < 0
+	case "!=":
+		return order != 0
+	default:
+		return order == 0
+	}
+}
+
+func parseVersion(text string) (version, error) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "v")
+
+	if i := strings.IndexByte(text, '+'); i >= 0 {
+		text = text[:i]
+	}
+
+	var v version
+
+	if i := strings.IndexByte(text, '-'); i >= 0 {
+		v.prerelease = text[i+1:]
+		text = text[:i]
+	}
+
+	for _, part := range strings.Split(text, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return v, fmt.Errorf("invalid version %q", text)
+		}
+
+		v.numbers = append(v.numbers, number)
+	}
+
+	return v, nil
+}
+
+// compare orders versions by their numeric components, treating missing
+// components as zero; a prerelease sorts before the release it precedes.
+func (v version) compare(other version) int {
+	for i := 0; i < max(len(v.numbers), len(other.numbers)); i++ {
+		var a, b int
+
+		if i < len(v.numbers) {
+			a = v.numbers[i]
+		}
+
+		if i < len(other.numbers) {
+			b = other.numbers[i]
+		}
+
+		if a != b {
+			if a < b {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.prerelease, other.prerelease)
+	}
+}
+
+// comparePrerelease orders prerelease tags as SemVer does: identifier by
+// identifier, numeric identifiers numerically and below alphanumeric ones,
+// and a tag below the longer tags it is a prefix of.
+func comparePrerelease(a, b string) int {
+	left := strings.Split(a, ".")
+	right := strings.Split(b, ".")
+
+	for i := 0; i < min(len(left), len(right)); i++ {
+		x, xErr := strconv.ParseUint(left[i], 10, 64)
+		y, yErr := strconv.ParseUint(right[i], 10, 64)
+
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+
+				return 1
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if order := strings.Compare(left[i], right[i]); order != 0 {
+				return order
+			}
+		}
+	}
+
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,65 @@
+package testing_test
+
+import (
+	stdtesting "testing"
+
+	testing2 "github.com/MontFerret/lab/v2/pkg/testing"
+)
+
+func TestVersionConstraintCheck(t *stdtesting.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{constraint: ">=2.1.0", version: "2.1.0", expected: true},
+		{constraint: ">=2.1.0", version: "v2.0.9", expected: false},
+		{constraint: ">=2.1, <3", version: "2.10.4", expected: true},
+		{constraint: ">=2.1, <3", version: "3.0.0", expected: false},
+		{constraint: "2.1", version: "2.1.0", expected: true},
+		{constraint: "!=2.1.0", version: "2.1.0", expected: false},
+		{constraint: ">=2.1.0", version: "2.1.0-rc.1", expected: false},
+		{constraint: ">2.0.0-beta", version: "2.0.0-rc.1", expected: true},
+		{constraint: ">=2", version: "Version: 2.0.1+abc", expected: true},
+		{constraint: ">=2.0.0-alpha.9", version: "v2.0.0-alpha.50", expected: true},
+		{constraint: "<2.0.0-alpha.50", version: "2.0.0-alpha.9", expected: true},
+		{constraint: ">2.0.0-alpha", version: "2.0.0-alpha.1", expected: true},
+		{constraint: ">2.0.0-alpha.1", version: "2.0.0-alpha", expected: false},
+		{constraint: ">2.0.0-alpha.50", version: "2.0.0-alpha.beta", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint+" "+test.version, func(t *stdtesting.T) {
+			constraint, err := testing2.ParseVersionConstraint(test.constraint)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			got, err := constraint.Check(test.version)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestVersionConstraintRejectsInvalidInput(t *stdtesting.T) {
+	for _, text := range []string{"", ">=", ">=two", "2.x"} {
+		if _, err := testing2.ParseVersionConstraint(text); err == nil {
+			t.Fatalf("expected error for %q", text)
+		}
+	}
+
+	constraint, err := testing2.ParseVersionConstraint(">=2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := constraint.Check("unknown"); err == nil {
+		t.Fatal("expected error for an unknown version")
+	}
+}
@@ -0,0 +1,190 @@
+package watcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	Options struct {
+		// Interval is the time between two scans of the watched paths.
+		Interval time.Duration
+	}
+
+	// Watcher detects changes of files by polling their size and modification
+	// time. A file whose content did not change is not reported, even when it
+	// was rewritten.
+	Watcher struct {
+		interval time.Duration
+		files    map[string]fileState
+	}
+
+	fileState struct {
+		modTime time.Time
+		size    int64
+		// hash is computed once the file is seen changing.
+		hash []byte
+	}
+)
+
+const DefaultInterval = 500 * time.Millisecond
+
+func New(opts Options) *Watcher {
+	interval := opts.Interval
+
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Watcher{
+		interval: interval,
+		files:    make(map[string]fileState),
+	}
+}
+
+// Scan records the current state of the paths and returns the files that
+// were created, changed or removed since the previous scan, sorted by name;
+// the first scan returns every file. Directories are walked recursively,
+// skipping hidden directories.
+func (w *Watcher) Scan(paths []string) []string {
+	current := walk(paths, len(w.files))
+	changed := make([]string, 0)
+
+	for name, state := range current {
+		previous, found := w.files[name]
+
+		switch {
+		case !found:
+			changed = append(changed, name)
+		case previous.modTime.Equal(state.modTime) && previous.size == state.size:
+			state.hash = previous.hash
+		default:
+			state.hash = hashFile(name)
+
+			if previous.hash == nil || state.hash == nil || string(previous.hash) != string(state.hash) {
+				changed = append(changed, name)
+			}
+		}
+
+		current[name] = state
+	}
+
+	for name := range w.files {
+		if _, found := current[name]; !found {
+			changed = append(changed, name)
+		}
+	}
+
+	w.files = current
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// Track records the current state of the files under the paths without
+// reporting them, so that the next scan reports only their later changes.
+// The state of other files is kept.
+func (w *Watcher) Track(paths []string) {
+	for name, state := range walk(paths, 0) {
+		w.files[name] = state
+	}
+}
+
+// walk returns the state of the regular files under the paths.
+func walk(paths []string, size int) map[string]fileState {
+	current := make(map[string]fileState, size)
+
+	for _, path := range paths {
+		_ = filepath.WalkDir(path, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if entry.IsDir() {
+				if name != path && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+
+			current[name] = fileState{modTime: info.ModTime(), size: info.Size()}
+
+			return nil
+		})
+	}
+
+	return current
+}
+
+// Wait scans the paths returned by the function until a scan reports changes
+// and then until the changes settle, i.e. a scan reports no further changes.
+// It returns the changed files.
+func (w *Watcher) Wait(ctx context.Context, paths func() []string) ([]string, error) {
+	changed := make(map[string]bool)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		names := w.Scan(paths())
+
+		if len(names) == 0 && len(changed) > 0 {
+			break
+		}
+
+		for _, name := range names {
+			changed[name] = true
+		}
+	}
+
+	res := make([]string, 0, len(changed))
+
+	for name := range changed {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res, nil
+}
+
+func hashFile(name string) []byte {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+
+	defer file.Close()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return nil
+	}
+
+	return h.Sum(nil)
+}
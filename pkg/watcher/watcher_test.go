@@ -0,0 +1,126 @@
+package watcher_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MontFerret/lab/v2/pkg/watcher"
+)
+
+func writeFile(t *testing.T, name string, content string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatalf("failed to set file time: %v", err)
+	}
+}
+
+func TestWatcherScanReportsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	a := filepath.Join(dir, "a.fql")
+	b := filepath.Join(dir, "nested", "b.fql")
+	hidden := filepath.Join(dir, ".git", "HEAD")
+
+	writeFile(t, a, "RETURN 1", base)
+	writeFile(t, b, "RETURN 2", base)
+	writeFile(t, hidden, "ref", base)
+
+	w := watcher.New(watcher.Options{})
+
+	if changed := w.Scan([]string{dir}); !reflect.DeepEqual(changed, []string{a, b}) {
+		t.Fatalf("expected the first scan to report every file, got %v", changed)
+	}
+
+	if changed := w.Scan([]string{dir}); len(changed) != 0 {
+		t.Fatalf("expected no changes, got %v", changed)
+	}
+
+	c := filepath.Join(dir, "c.fql")
+
+	writeFile(t, a, "RETURN 11", base.Add(time.Second))
+	writeFile(t, c, "RETURN 3", base)
+	writeFile(t, hidden, "other", base.Add(time.Second))
+
+	if err := os.Remove(b); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+
+	if changed := w.Scan([]string{dir}); !reflect.DeepEqual(changed, []string{a, c, b}) {
+		t.Fatalf("expected changed, removed and created files, got %v", changed)
+	}
+
+	// the content of a is known once it changed, so rewriting it is no change
+	writeFile(t, a, "RETURN 11", base.Add(2*time.Second))
+
+	if changed := w.Scan([]string{dir}); len(changed) != 0 {
+		t.Fatalf("expected a rewrite with the same content to be ignored, got %v", changed)
+	}
+}
+
+func TestWatcherTrackIgnoresTrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	a := filepath.Join(dir, "a.fql")
+	snapshot := filepath.Join(dir, "a.snap.json")
+
+	writeFile(t, a, "RETURN 1", base)
+
+	w := watcher.New(watcher.Options{})
+	w.Scan([]string{dir})
+
+	writeFile(t, a, "RETURN 2", base.Add(time.Second))
+	writeFile(t, snapshot, "1", base)
+
+	w.Track([]string{snapshot})
+
+	if changed := w.Scan([]string{dir}); !reflect.DeepEqual(changed, []string{a}) {
+		t.Fatalf("expected only the untracked change, got %v", changed)
+	}
+}
+
+func TestWatcherWaitReturnsSettledChanges(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.fql")
+	base := time.Now().Add(-time.Hour)
+
+	writeFile(t, a, "RETURN 1", base)
+
+	w := watcher.New(watcher.Options{Interval: 5 * time.Millisecond})
+	w.Scan([]string{dir})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		writeFile(t, a, "RETURN 2", base.Add(time.Second))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changed, err := w.Wait(ctx, func() []string { return []string{dir} })
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(changed, []string{a}) {
+		t.Fatalf("expected %v, got %v", []string{a}, changed)
+	}
+
+	cancel()
+
+	if _, err := w.Wait(ctx, func() []string { return []string{dir} }); err == nil {
+		t.Fatalf("expected an error once the context is cancelled")
+	}
+}